@@ -16,9 +16,23 @@ import (
 
 	"github.com/sivaratrisrinivas/web3/blockCheck/config"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/auth"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/bytecode"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache"
+	cachefactory "github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/factory"
+	cacheredis "github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/redis"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/ens"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/head"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels"
+	labelsfactory "github.com/sivaratrisrinivas/web3/blockCheck/internal/labels/factory"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/poisoning"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/screening"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/signatures"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/ethereum"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/warmup"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/watchlist"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/webhook"
 	"github.com/sivaratrisrinivas/web3/blockCheck/pkg/handlers"
 )
 
@@ -47,6 +61,37 @@ func main() {
 		zap.Int("port", cfg.Server.Port),
 		zap.String("env", cfg.Log.Environment))
 
+	// Initialize cache
+	appCache, err := cachefactory.NewCache(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s cache: %v", cfg.Cache.Type, err)
+	}
+	defer appCache.Close()
+	addressCache := cache.NewAddressCache(appCache, cache.TTLs{
+		ENS:                  cfg.Cache.ENSTTL,
+		ReverseENS:           cfg.Cache.ReverseENSTTL,
+		Contract:             cfg.Cache.ContractTTL,
+		TokenMetadata:        cfg.Cache.TokenMetadataTTL,
+		Negative:             cfg.Cache.NegativeTTL,
+		StaleWhileRevalidate: cfg.Cache.StaleWhileRevalidate,
+		StaleIfError:         cfg.Cache.StaleIfError,
+	})
+	if cfg.Cache.DistributedLock {
+		locker, ok := appCache.(cache.Locker)
+		if !ok {
+			log.Fatalf("CACHE_DISTRIBUTED_LOCK is not supported by the %s cache", cfg.Cache.Type)
+		}
+		addressCache.SetLocker(locker, cfg.Cache.LockTimeout)
+	}
+
+	// Initialize head tracking, shared by all chain validators. Cached
+	// results are tagged with the block they were computed at.
+	heads := head.NewTracker(head.Config{
+		PollInterval: cfg.Head.PollInterval,
+		Window:       cfg.Head.WindowBlocks,
+	})
+	addressCache.SetHeadTracker(heads, cfg.Cache.HeadExpiryBlocks)
+
 	// Initialize validator factory and registry
 	factory := chain.NewFactory()
 	registry := chain.NewRegistry()
@@ -58,8 +103,9 @@ func main() {
 
 	// Create and register Ethereum validator instance
 	ethConfig := map[string]interface{}{
-		"provider_url":   cfg.ENS.ProviderURL,
-		"cache_duration": int64(cfg.Cache.TTL.Seconds()),
+		"provider_url":  cfg.ENS.ProviderURL,
+		"address_cache": addressCache,
+		"head_tracker":  heads,
 	}
 
 	log.Debugf("Creating Ethereum validator with config: %+v", ethConfig)
@@ -73,9 +119,98 @@ func main() {
 		log.Fatalf("Failed to register Ethereum validator instance: %v", err)
 	}
 
+	// Initialize cache warm-up jobs
+	warmups := warmup.NewService(registry, warmup.Config{
+		Concurrency: cfg.Cache.WarmupConcurrency,
+		MaxEntries:  cfg.Cache.WarmupMaxEntries,
+		MaxJobs:     cfg.Cache.WarmupMaxJobs,
+		Timeout:     time.Duration(cfg.ENS.TimeoutSeconds) * time.Second,
+	})
+
+	// Initialize bytecode fingerprinting
+	fingerprints, err := bytecode.NewService(cfg.Analysis.FingerprintCataloguePath)
+	if err != nil {
+		log.Fatalf("Failed to load bytecode catalogue: %v", err)
+	}
+
+	// Initialize selector/topic signature database
+	sigs, err := signatures.NewService(cfg.Analysis.SignatureDBPath)
+	if err != nil {
+		log.Fatalf("Failed to load signature database: %v", err)
+	}
+
+	// Initialize sanctions and denylist screening
+	screeningSources := make([]screening.Source, 0, len(cfg.Screening.Lists))
+	for _, list := range cfg.Screening.Lists {
+		screeningSources = append(screeningSources, screening.Source{
+			Name:  list.Name,
+			Kind:  list.Kind,
+			Path:  list.Path,
+			Chain: list.Chain,
+		})
+	}
+	screener, err := screening.NewService(screeningSources)
+	if err != nil {
+		log.Fatalf("Failed to load screening lists: %v", err)
+	}
+
+	// Initialize ENS impersonation checks
+	ensRisk := ens.NewRiskChecker(cfg.ENS.ProtectedNames)
+
+	// Initialize address labels
+	labelStore, err := labelsfactory.NewStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize %s label store: %v", cfg.Labels.StoreType, err)
+	}
+	defer labelStore.Close()
+
+	// Initialize address poisoning detection
+	poisoningDetector := poisoning.NewDetector(
+		poisoning.NewRecentStore(cfg.Poisoning.RecentMax, cfg.Poisoning.RecentMaxKeys, cfg.Poisoning.RecentTTL),
+		labels.NewAddressBook(labelStore))
+
+	// Initialize webhook delivery
+	webhooks := webhook.NewService(webhook.Config{
+		MaxAttempts:   cfg.Webhook.MaxAttempts,
+		BaseBackoff:   cfg.Webhook.BaseBackoff,
+		MaxBackoff:    cfg.Webhook.MaxBackoff,
+		Timeout:       cfg.Webhook.Timeout,
+		MaxEndpoints:  cfg.Webhook.MaxEndpoints,
+		MaxDeliveries: cfg.Webhook.MaxDeliveries,
+		AllowPrivate:  cfg.Webhook.AllowPrivate,
+	})
+
+	// Initialize watchlists, pushing detected changes to webhooks
+	watchlists := watchlist.New(cfg.Watchlist.MaxItems, cfg.Watchlist.MaxEvents)
+	watchPoller := watchlist.NewPoller(watchlists, registry, screener, cfg.Watchlist.PollInterval, cfg.Watchlist.ExpiryWarning)
+	watchPoller.OnEvent(func(e watchlist.Event) {
+		webhooks.Publish(e.Owner, e.Type, e)
+	})
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	defer stopPolling()
+	go heads.Run(pollCtx)
+	go watchPoller.Run(pollCtx)
+	go webhooks.Run(pollCtx)
+
 	// Initialize JWT auth
 	jwtAuth := auth.NewJWTAuth(cfg.JWT.SecretKey, cfg.JWT.Duration)
 
+	// Initialize Sign-In with Ethereum. Replicas sharing a Redis cache also
+	// share nonces, outside the cache so that they are never evicted.
+	var nonces auth.NonceStore = auth.NewMemoryNonceStore()
+	if cfg.Cache.Type == "redis" || cfg.Cache.Type == "tiered" {
+		nonceClient, err := cacheredis.NewClient(cachefactory.RedisConfig(cfg))
+		if err != nil {
+			log.Fatalf("Failed to initialize SIWE nonce store: %v", err)
+		}
+		defer nonceClient.Close()
+		nonces = auth.NewRedisNonceStore(nonceClient, cfg.SIWE.NonceKeyPrefix)
+	}
+	siweAuth, err := auth.NewSIWEAuth(cfg.SIWE.Domain, cfg.SIWE.URI, cfg.SIWE.NonceTTL, nonces, registry)
+	if err != nil {
+		log.Fatalf("Failed to initialize SIWE: %v", err)
+	}
+
 	// Initialize router
 	r := chi.NewRouter()
 
@@ -97,13 +232,61 @@ func main() {
 	// Public routes
 	r.Get("/health", handlers.HealthCheckHandler)
 	r.Post("/v1/token", handlers.GenerateTokenHandler(jwtAuth))
+	r.Get("/v1/auth/nonce", handlers.SIWENonceHandler(siweAuth))
+	r.Post("/v1/auth/siwe", handlers.SIWELoginHandler(jwtAuth, siweAuth))
+	r.Post("/v1/admin/token", handlers.GenerateAdminTokenHandler(jwtAuth, cfg.JWT.AdminSecret))
 
 	// Protected routes
 	r.Group(func(r chi.Router) {
 		r.Use(jwtAuth.Middleware)
-		r.Get("/v1/validate/{address}", handlers.ValidateAddressHandler(ethValidator))
-		r.Get("/v1/resolveEns/{name}", handlers.ResolveENSHandler(ethValidator))
-		r.Get("/v1/isContract/{address}", handlers.IsContractHandler(ethValidator))
+		r.Use(poisoningDetector.Middleware)
+		r.Use(cache.TraceMiddleware)
+		r.Get("/v1/validate/{address}", handlers.ValidateAddressHandler(ethValidator, screener, labelStore))
+		r.Get("/v1/resolveEns/{name}", handlers.ResolveENSHandler(ethValidator, screener, ensRisk, labelStore))
+		r.Get("/v1/isContract/{address}", handlers.IsContractHandler(ethValidator, labelStore))
+		r.Post("/v1/computeAddress", handlers.ComputeAddressHandler(ethValidator))
+		r.Get("/v1/fingerprint/{address}", handlers.FingerprintHandler(ethValidator, fingerprints))
+		r.Get("/v1/analyze/{address}", handlers.AnalyzeHandler(ethValidator, sigs))
+		r.Get("/v1/{chain}/accounts/{address}", handlers.AccountHandler(registry, screener))
+		r.Post("/v1/{chain}/accounts", handlers.AccountsBatchHandler(registry, screener))
+		r.Post("/v1/{chain}/tokens/balances", handlers.TokenBalancesHandler(registry, addressCache))
+		r.Post("/v1/{chain}/tokens/allowances", handlers.TokenAllowancesHandler(registry, addressCache))
+		r.Post("/v1/verify/message", handlers.VerifyMessageHandler(registry))
+		r.Post("/v1/verify/typedData", handlers.VerifyTypedDataHandler(registry))
+		r.Post("/v1/decode/tx", handlers.DecodeTxHandler(registry))
+		r.Post("/v1/decode/calldata", handlers.DecodeCalldataHandler(registry, sigs))
+		r.Post("/v1/poisoning/check", handlers.PoisoningCheckHandler(poisoningDetector))
+		r.Get("/v1/{chain}/labels", handlers.ListLabelsHandler(registry, labelStore))
+		r.Get("/v1/{chain}/labels/{address}", handlers.GetLabelHandler(registry, labelStore))
+		r.Put("/v1/{chain}/labels/{address}", handlers.PutLabelHandler(registry, labelStore))
+		r.Delete("/v1/{chain}/labels/{address}", handlers.DeleteLabelHandler(registry, labelStore))
+		r.Post("/v1/watchlist", handlers.AddWatchlistItemHandler(registry, watchlists, watchPoller))
+		r.Get("/v1/watchlist", handlers.ListWatchlistHandler(watchlists))
+		r.Get("/v1/watchlist/events", handlers.WatchlistEventsHandler(watchlists))
+		r.Get("/v1/watchlist/{id}", handlers.GetWatchlistItemHandler(watchlists))
+		r.Delete("/v1/watchlist/{id}", handlers.DeleteWatchlistItemHandler(watchlists))
+		r.Post("/v1/webhooks", handlers.RegisterWebhookHandler(webhooks))
+		r.Get("/v1/webhooks", handlers.ListWebhooksHandler(webhooks))
+		r.Delete("/v1/webhooks/{id}", handlers.DeleteWebhookHandler(webhooks))
+		r.Get("/v1/webhooks/deliveries", handlers.WebhookDeliveriesHandler(webhooks))
+		r.Get("/v1/webhooks/deliveries/{id}", handlers.WebhookDeliveryHandler(webhooks))
+		r.Post("/v1/webhooks/deliveries/{id}/replay", handlers.ReplayWebhookDeliveryHandler(webhooks))
+	})
+
+	// Admin routes, for tokens exchanged for ADMIN_SECRET
+	r.Group(func(r chi.Router) {
+		r.Use(jwtAuth.Middleware)
+		r.Use(auth.RequireScope(auth.ScopeAdmin))
+		r.Get("/v1/admin/cache/stats", handlers.CacheStatsHandler(addressCache))
+		r.Get("/v1/admin/cache/entries/{key}", handlers.GetCacheEntryHandler(addressCache))
+		r.Delete("/v1/admin/cache/entries/{key}", handlers.DeleteCacheEntryHandler(addressCache))
+		r.Delete("/v1/admin/cache/entries", handlers.PurgeCacheEntriesHandler(addressCache))
+		r.Delete("/v1/admin/cache", handlers.PurgeCacheHandler(addressCache))
+		r.Post("/v1/admin/cache/warm", handlers.StartCacheWarmupHandler(warmups))
+		r.Get("/v1/admin/cache/warm", handlers.ListCacheWarmupsHandler(warmups))
+		r.Get("/v1/admin/cache/warm/{id}", handlers.GetCacheWarmupHandler(warmups))
+		r.Delete("/v1/admin/cache/warm/{id}", handlers.CancelCacheWarmupHandler(warmups))
+		r.Get("/v1/admin/heads", handlers.ChainHeadsHandler(heads))
 	})
 
 	// Start server
@@ -130,6 +313,7 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down server...")
+	stopPolling()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
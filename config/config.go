@@ -3,20 +3,30 @@ package config
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Server ServerConfig
-	ENS    ENSConfig
-	Cache  CacheConfig
-	Redis  RedisConfig
-	API    APIConfig
-	JWT    JWTConfig
-	Log    LogConfig
+	Server    ServerConfig
+	ENS       ENSConfig
+	Cache     CacheConfig
+	Redis     RedisConfig
+	API       APIConfig
+	JWT       JWTConfig
+	SIWE      SIWEConfig
+	Log       LogConfig
+	Analysis  AnalysisConfig
+	Screening ScreeningConfig
+	Poisoning PoisoningConfig
+	Labels    LabelsConfig
+	Watchlist WatchlistConfig
+	Webhook   WebhookConfig
+	Head      HeadConfig
 }
 
 type ServerConfig struct {
@@ -28,11 +38,44 @@ type ENSConfig struct {
 	ProviderURL    string
 	TimeoutSeconds int
 	RetryAttempts  int
+	ProtectedNames []string
 }
 
 type CacheConfig struct {
 	Type string
 	TTL  time.Duration
+	// Per data type TTLs; ENS lookups default to TTL
+	ENSTTL           time.Duration
+	ReverseENSTTL    time.Duration
+	ContractTTL      time.Duration
+	TokenMetadataTTL time.Duration
+	// NegativeTTL caches lookups that found nothing; zero disables it
+	NegativeTTL time.Duration
+	// Stale values are served while refreshed, or when the node fails
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+	// DistributedLock coalesces loads across replicas sharing a Redis cache
+	DistributedLock bool
+	LockTimeout     time.Duration
+	// Memory cache bounds
+	MaxEntries     int
+	MaxBytes       int64
+	EvictionPolicy string
+	Shards         int
+	// Tiered cache: how long L1 keeps entries and the Redis channel
+	// invalidations are broadcast on
+	L1TTL               time.Duration
+	InvalidationChannel string
+	// Disk cache directory and how often writes are synced to it
+	DiskDir          string
+	DiskSyncInterval time.Duration
+	// Warm-up jobs started through the admin API
+	WarmupConcurrency int
+	WarmupMaxEntries  int
+	WarmupMaxJobs     int
+	// HeadExpiryBlocks expires entries computed at a non-final block once
+	// the head is this many blocks past it; zero leaves them to their TTL
+	HeadExpiryBlocks uint64
 }
 
 type RedisConfig struct {
@@ -40,6 +83,24 @@ type RedisConfig struct {
 	Port     int
 	Password string
 	DB       int
+	// Mode is standalone, sentinel or cluster; Addrs lists the sentinels or
+	// cluster seed nodes
+	Mode             string
+	Addrs            []string
+	MasterName       string
+	Username         string
+	SentinelUsername string
+	SentinelPassword string
+	// KeyPrefix namespaces the cache's keys. Clearing or purging the cache
+	// deletes keys under it, so everything else kept in Redis (locks, SIWE
+	// nonces and labels) has its own prefix that must not fall under it.
+	KeyPrefix string
+	// LockKeyPrefix namespaces the locks shared by replicas
+	LockKeyPrefix         string
+	TLS                   bool
+	TLSCAFile             string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
 }
 
 type APIConfig struct {
@@ -53,6 +114,73 @@ type APIConfig struct {
 type JWTConfig struct {
 	SecretKey string
 	Duration  time.Duration
+	// AdminSecret is exchanged for admin-scoped tokens; empty disables the
+	// admin API
+	AdminSecret string
+}
+
+type AnalysisConfig struct {
+	FingerprintCataloguePath string
+	SignatureDBPath          string
+}
+
+type SIWEConfig struct {
+	Domain   string
+	URI      string
+	NonceTTL time.Duration
+	// NonceKeyPrefix namespaces nonces in Redis
+	NonceKeyPrefix string
+}
+
+// ScreeningListConfig describes one screening list file
+type ScreeningListConfig struct {
+	Name string
+	Kind string
+	Path string
+	// Chain applies to entries that do not name a chain; empty means all chains
+	Chain string
+}
+
+type ScreeningConfig struct {
+	Lists []ScreeningListConfig
+}
+
+type PoisoningConfig struct {
+	RecentMax int
+	// RecentMaxKeys bounds how many API keys have recent addresses kept
+	RecentMaxKeys int
+	RecentTTL     time.Duration
+}
+
+type LabelsConfig struct {
+	StoreType string
+	FilePath  string
+	// KeyPrefix namespaces labels in Redis
+	KeyPrefix string
+}
+
+type WatchlistConfig struct {
+	PollInterval  time.Duration
+	ExpiryWarning time.Duration
+	MaxItems      int
+	MaxEvents     int
+}
+
+type WebhookConfig struct {
+	MaxAttempts   int
+	BaseBackoff   time.Duration
+	MaxBackoff    time.Duration
+	Timeout       time.Duration
+	MaxEndpoints  int
+	MaxDeliveries int
+	AllowPrivate  bool
+}
+
+// HeadConfig sets how the latest and finalized blocks are tracked
+type HeadConfig struct {
+	PollInterval time.Duration
+	// WindowBlocks is how many recent block hashes are kept to detect reorgs
+	WindowBlocks uint64
 }
 
 type LogConfig struct {
@@ -92,6 +220,12 @@ func LoadConfig() (*Config, error) {
 	}
 	cfg.ENS.RetryAttempts = retryAttempts
 
+	for _, name := range strings.Split(getEnvString("ENS_PROTECTED_NAMES", ""), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.ENS.ProtectedNames = append(cfg.ENS.ProtectedNames, name)
+		}
+	}
+
 	// Cache Config
 	cfg.Cache.Type = getEnvString("CACHE_TYPE", "memory")
 	ttlMinutes, err := getEnvInt("CACHE_TTL_MINUTES", 60)
@@ -99,6 +233,105 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid CACHE_TTL_MINUTES: %w", err)
 	}
 	cfg.Cache.TTL = time.Duration(ttlMinutes) * time.Minute
+	ensTTL, err := getEnvInt("CACHE_ENS_TTL_MINUTES", ttlMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_ENS_TTL_MINUTES: %w", err)
+	}
+	cfg.Cache.ENSTTL = time.Duration(ensTTL) * time.Minute
+	reverseTTL, err := getEnvInt("CACHE_REVERSE_ENS_TTL_MINUTES", ttlMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_REVERSE_ENS_TTL_MINUTES: %w", err)
+	}
+	cfg.Cache.ReverseENSTTL = time.Duration(reverseTTL) * time.Minute
+	// Short by default: an address without code can be deployed to at any time
+	contractTTL, err := getEnvInt("CACHE_CONTRACT_TTL_MINUTES", 5)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_CONTRACT_TTL_MINUTES: %w", err)
+	}
+	cfg.Cache.ContractTTL = time.Duration(contractTTL) * time.Minute
+	tokenTTL, err := getEnvInt("CACHE_TOKEN_METADATA_TTL_MINUTES", 1440)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_TOKEN_METADATA_TTL_MINUTES: %w", err)
+	}
+	cfg.Cache.TokenMetadataTTL = time.Duration(tokenTTL) * time.Minute
+	negativeTTL, err := getEnvInt("CACHE_NEGATIVE_TTL_SECONDS", 60)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_NEGATIVE_TTL_SECONDS: %w", err)
+	}
+	cfg.Cache.NegativeTTL = time.Duration(negativeTTL) * time.Second
+	staleWhileRevalidate, err := getEnvInt("CACHE_STALE_WHILE_REVALIDATE_MINUTES", 10)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_STALE_WHILE_REVALIDATE_MINUTES: %w", err)
+	}
+	cfg.Cache.StaleWhileRevalidate = time.Duration(staleWhileRevalidate) * time.Minute
+	staleIfError, err := getEnvInt("CACHE_STALE_IF_ERROR_MINUTES", 60)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_STALE_IF_ERROR_MINUTES: %w", err)
+	}
+	cfg.Cache.StaleIfError = time.Duration(staleIfError) * time.Minute
+	cfg.Cache.DistributedLock = getEnvBool("CACHE_DISTRIBUTED_LOCK", false)
+	lockTimeout, err := getEnvInt("CACHE_LOCK_TIMEOUT_SECONDS", 5)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_LOCK_TIMEOUT_SECONDS: %w", err)
+	}
+	cfg.Cache.LockTimeout = time.Duration(lockTimeout) * time.Second
+	maxEntries, err := getEnvInt("CACHE_MAX_ENTRIES", 100000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_MAX_ENTRIES: %w", err)
+	}
+	cfg.Cache.MaxEntries = maxEntries
+	maxMB, err := getEnvInt("CACHE_MAX_MB", 256)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_MAX_MB: %w", err)
+	}
+	cfg.Cache.MaxBytes = int64(maxMB) << 20
+	cfg.Cache.EvictionPolicy = getEnvString("CACHE_EVICTION_POLICY", "tinylfu")
+	if cfg.Cache.EvictionPolicy != "lru" && cfg.Cache.EvictionPolicy != "tinylfu" {
+		return nil, fmt.Errorf("invalid CACHE_EVICTION_POLICY %q: expected lru or tinylfu", cfg.Cache.EvictionPolicy)
+	}
+	shards, err := getEnvInt("CACHE_SHARDS", 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_SHARDS: %w", err)
+	}
+	cfg.Cache.Shards = shards
+	l1TTL, err := getEnvInt("CACHE_L1_TTL_SECONDS", 60)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_L1_TTL_SECONDS: %w", err)
+	}
+	cfg.Cache.L1TTL = time.Duration(l1TTL) * time.Second
+	cfg.Cache.InvalidationChannel = getEnvString("CACHE_INVALIDATION_CHANNEL", "blockcheck:cache:invalidate")
+	cfg.Cache.DiskDir = getEnvString("CACHE_DISK_DIR", "data/cache")
+	diskSync, err := getEnvInt("CACHE_DISK_SYNC_SECONDS", 1)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_DISK_SYNC_SECONDS: %w", err)
+	}
+	cfg.Cache.DiskSyncInterval = time.Duration(diskSync) * time.Second
+	warmupConcurrency, err := getEnvInt("CACHE_WARMUP_CONCURRENCY", 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_WARMUP_CONCURRENCY: %w", err)
+	}
+	cfg.Cache.WarmupConcurrency = warmupConcurrency
+	warmupMaxEntries, err := getEnvInt("CACHE_WARMUP_MAX_ENTRIES", 10000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_WARMUP_MAX_ENTRIES: %w", err)
+	}
+	cfg.Cache.WarmupMaxEntries = warmupMaxEntries
+	warmupMaxJobs, err := getEnvInt("CACHE_WARMUP_MAX_JOBS", 100)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_WARMUP_MAX_JOBS: %w", err)
+	}
+	if warmupMaxJobs <= 0 {
+		return nil, fmt.Errorf("CACHE_WARMUP_MAX_JOBS must be positive")
+	}
+	cfg.Cache.WarmupMaxJobs = warmupMaxJobs
+	headExpiry, err := getEnvInt("CACHE_HEAD_EXPIRY_BLOCKS", 0)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CACHE_HEAD_EXPIRY_BLOCKS: %w", err)
+	}
+	if headExpiry < 0 {
+		return nil, fmt.Errorf("CACHE_HEAD_EXPIRY_BLOCKS must not be negative")
+	}
+	cfg.Cache.HeadExpiryBlocks = uint64(headExpiry)
 
 	// Redis Config
 	cfg.Redis.Host = getEnvString("REDIS_HOST", "localhost")
@@ -113,6 +346,31 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid REDIS_DB: %w", err)
 	}
 	cfg.Redis.DB = redisDB
+	cfg.Redis.Mode = getEnvString("REDIS_MODE", "standalone")
+	if cfg.Redis.Mode != "standalone" && cfg.Redis.Mode != "sentinel" && cfg.Redis.Mode != "cluster" {
+		return nil, fmt.Errorf("invalid REDIS_MODE %q: expected standalone, sentinel or cluster", cfg.Redis.Mode)
+	}
+	for _, addr := range strings.Split(getEnvString("REDIS_ADDRS", ""), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.Redis.Addrs = append(cfg.Redis.Addrs, addr)
+		}
+	}
+	cfg.Redis.MasterName = getEnvString("REDIS_MASTER_NAME", "")
+	if cfg.Redis.Mode == "sentinel" && cfg.Redis.MasterName == "" {
+		return nil, fmt.Errorf("REDIS_MASTER_NAME is required when REDIS_MODE is sentinel")
+	}
+	cfg.Redis.Username = getEnvString("REDIS_USERNAME", "")
+	cfg.Redis.SentinelUsername = getEnvString("REDIS_SENTINEL_USERNAME", "")
+	cfg.Redis.SentinelPassword = getEnvString("REDIS_SENTINEL_PASSWORD", "")
+	cfg.Redis.KeyPrefix = getEnvString("REDIS_KEY_PREFIX", "blockcheck:")
+	if cfg.Redis.KeyPrefix == "" {
+		return nil, fmt.Errorf("REDIS_KEY_PREFIX must not be empty")
+	}
+	cfg.Redis.LockKeyPrefix = getEnvString("REDIS_LOCK_KEY_PREFIX", "blockcheck-lock:")
+	cfg.Redis.TLS = getEnvBool("REDIS_TLS", false)
+	cfg.Redis.TLSCAFile = getEnvString("REDIS_TLS_CA_FILE", "")
+	cfg.Redis.TLSServerName = getEnvString("REDIS_TLS_SERVER_NAME", "")
+	cfg.Redis.TLSInsecureSkipVerify = getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false)
 
 	// API Config
 	cfg.API.EnableRateLimit = getEnvBool("ENABLE_RATE_LIMIT", true)
@@ -139,14 +397,189 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid JWT_DURATION_MINUTES: %w", err)
 	}
 	cfg.JWT.Duration = time.Duration(jwtDuration) * time.Minute
+	cfg.JWT.AdminSecret = getEnvString("ADMIN_SECRET", "")
+
+	// SIWE Config
+	cfg.SIWE.Domain = getEnvString("SIWE_DOMAIN", fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port))
+	cfg.SIWE.URI = getEnvString("SIWE_URI", "http://"+cfg.SIWE.Domain)
+	nonceTTL, err := getEnvInt("SIWE_NONCE_TTL_SECONDS", 300)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SIWE_NONCE_TTL_SECONDS: %w", err)
+	}
+	cfg.SIWE.NonceTTL = time.Duration(nonceTTL) * time.Second
+	cfg.SIWE.NonceKeyPrefix = getEnvString("SIWE_NONCE_KEY_PREFIX", "blockcheck-siwe:")
 
 	// Log Config
 	cfg.Log.Environment = getEnvString("LOG_ENVIRONMENT", "development")
 	cfg.Log.Level = getEnvString("LOG_LEVEL", "info")
 
+	// Analysis Config
+	cfg.Analysis.FingerprintCataloguePath = getEnvString("FINGERPRINT_CATALOGUE_PATH", "")
+	cfg.Analysis.SignatureDBPath = getEnvString("SIGNATURE_DB_PATH", "")
+
+	// Screening Config
+	lists, err := parseScreeningLists(getEnvString("SCREENING_LISTS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SCREENING_LISTS: %w", err)
+	}
+	cfg.Screening.Lists = lists
+
+	// Poisoning Config
+	recentMax, err := getEnvInt("POISONING_RECENT_MAX", 500)
+	if err != nil {
+		return nil, fmt.Errorf("invalid POISONING_RECENT_MAX: %w", err)
+	}
+	cfg.Poisoning.RecentMax = recentMax
+	recentMaxKeys, err := getEnvInt("POISONING_RECENT_MAX_KEYS", 10000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid POISONING_RECENT_MAX_KEYS: %w", err)
+	}
+	if recentMaxKeys <= 0 {
+		return nil, fmt.Errorf("POISONING_RECENT_MAX_KEYS must be positive")
+	}
+	cfg.Poisoning.RecentMaxKeys = recentMaxKeys
+	recentTTL, err := getEnvInt("POISONING_RECENT_TTL_HOURS", 720)
+	if err != nil {
+		return nil, fmt.Errorf("invalid POISONING_RECENT_TTL_HOURS: %w", err)
+	}
+	cfg.Poisoning.RecentTTL = time.Duration(recentTTL) * time.Hour
+
+	// Labels Config
+	cfg.Labels.StoreType = getEnvString("LABELS_STORE_TYPE", "memory")
+	cfg.Labels.FilePath = getEnvString("LABELS_FILE_PATH", "labels.json")
+	cfg.Labels.KeyPrefix = getEnvString("LABELS_KEY_PREFIX", "blockcheck-labels:")
+
+	if err := checkOutsideKeyPrefix(cfg.Redis.KeyPrefix, map[string]string{
+		"REDIS_LOCK_KEY_PREFIX": cfg.Redis.LockKeyPrefix,
+		"SIWE_NONCE_KEY_PREFIX": cfg.SIWE.NonceKeyPrefix,
+		"LABELS_KEY_PREFIX":     cfg.Labels.KeyPrefix,
+	}); err != nil {
+		return nil, err
+	}
+
+	// Watchlist Config
+	pollInterval, err := getEnvInt("WATCHLIST_POLL_INTERVAL_SECONDS", 300)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WATCHLIST_POLL_INTERVAL_SECONDS: %w", err)
+	}
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("WATCHLIST_POLL_INTERVAL_SECONDS must be positive")
+	}
+	cfg.Watchlist.PollInterval = time.Duration(pollInterval) * time.Second
+	expiryWarning, err := getEnvInt("WATCHLIST_EXPIRY_WARNING_DAYS", 30)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WATCHLIST_EXPIRY_WARNING_DAYS: %w", err)
+	}
+	cfg.Watchlist.ExpiryWarning = time.Duration(expiryWarning) * 24 * time.Hour
+	maxItems, err := getEnvInt("WATCHLIST_MAX_ITEMS", 100)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WATCHLIST_MAX_ITEMS: %w", err)
+	}
+	cfg.Watchlist.MaxItems = maxItems
+	maxEvents, err := getEnvInt("WATCHLIST_MAX_EVENTS", 1000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WATCHLIST_MAX_EVENTS: %w", err)
+	}
+	cfg.Watchlist.MaxEvents = maxEvents
+
+	// Webhook Config
+	maxAttempts, err := getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_ATTEMPTS: %w", err)
+	}
+	if maxAttempts <= 0 {
+		return nil, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be positive")
+	}
+	cfg.Webhook.MaxAttempts = maxAttempts
+	baseBackoff, err := getEnvInt("WEBHOOK_BACKOFF_SECONDS", 10)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_BACKOFF_SECONDS: %w", err)
+	}
+	cfg.Webhook.BaseBackoff = time.Duration(baseBackoff) * time.Second
+	maxBackoff, err := getEnvInt("WEBHOOK_MAX_BACKOFF_SECONDS", 3600)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_BACKOFF_SECONDS: %w", err)
+	}
+	cfg.Webhook.MaxBackoff = time.Duration(maxBackoff) * time.Second
+	webhookTimeout, err := getEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_TIMEOUT_SECONDS: %w", err)
+	}
+	cfg.Webhook.Timeout = time.Duration(webhookTimeout) * time.Second
+	maxEndpoints, err := getEnvInt("WEBHOOK_MAX_ENDPOINTS", 10)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_ENDPOINTS: %w", err)
+	}
+	cfg.Webhook.MaxEndpoints = maxEndpoints
+	maxDeliveries, err := getEnvInt("WEBHOOK_MAX_DELIVERIES", 1000)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WEBHOOK_MAX_DELIVERIES: %w", err)
+	}
+	cfg.Webhook.MaxDeliveries = maxDeliveries
+	cfg.Webhook.AllowPrivate = getEnvBool("WEBHOOK_ALLOW_PRIVATE_TARGETS", false)
+
+	// Head Tracking Config
+	headPoll, err := getEnvInt("HEAD_POLL_INTERVAL_SECONDS", 12)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HEAD_POLL_INTERVAL_SECONDS: %w", err)
+	}
+	if headPoll <= 0 {
+		return nil, fmt.Errorf("HEAD_POLL_INTERVAL_SECONDS must be positive")
+	}
+	cfg.Head.PollInterval = time.Duration(headPoll) * time.Second
+	headWindow, err := getEnvInt("HEAD_WINDOW_BLOCKS", 128)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HEAD_WINDOW_BLOCKS: %w", err)
+	}
+	if headWindow <= 0 {
+		return nil, fmt.Errorf("HEAD_WINDOW_BLOCKS must be positive")
+	}
+	cfg.Head.WindowBlocks = uint64(headWindow)
+
 	return cfg, nil
 }
 
+// parseScreeningLists parses a comma separated list of name:kind:path[:chain]
+func parseScreeningLists(value string) ([]ScreeningListConfig, error) {
+	var lists []ScreeningListConfig
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) < 3 || len(parts) > 4 {
+			return nil, fmt.Errorf("expected name:kind:path[:chain], got %q", item)
+		}
+		list := ScreeningListConfig{
+			Name: parts[0],
+			Kind: parts[1],
+			Path: parts[2],
+		}
+		if len(parts) == 4 {
+			list.Chain = parts[3]
+		}
+		lists = append(lists, list)
+	}
+	return lists, nil
+}
+
+// checkOutsideKeyPrefix requires each of the named Redis prefixes to be set
+// and not to fall under the cache's key prefix
+func checkOutsideKeyPrefix(keyPrefix string, prefixes map[string]string) error {
+	names := make([]string, 0, len(prefixes))
+	for name := range prefixes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if prefix := prefixes[name]; prefix == "" || strings.HasPrefix(prefix, keyPrefix) {
+			return fmt.Errorf("%s must be set outside REDIS_KEY_PREFIX", name)
+		}
+	}
+	return nil
+}
+
 func getEnvString(key string, defaultValue string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
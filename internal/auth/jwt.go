@@ -15,8 +15,18 @@ var (
 	log = logrus.New()
 )
 
+// apiKeyContextKey holds the authenticated API key in the request context
+const apiKeyContextKey = "api_key"
+
+// scopesContextKey holds the scopes granted to the token in the request context
+const scopesContextKey = "scopes"
+
+// ScopeAdmin grants access to the operator endpoints
+const ScopeAdmin = "admin"
+
 type Claims struct {
-	APIKey string `json:"api_key"`
+	APIKey string   `json:"api_key"`
+	Scopes []string `json:"scopes,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -46,6 +56,40 @@ func (j *JWTAuth) GenerateToken(apiKey string) (string, error) {
 	return token.SignedString(j.secretKey)
 }
 
+// GenerateWalletToken creates a new JWT token whose subject is a wallet
+// address authenticated with Sign-In with Ethereum. The address doubles as
+// the API key so that per-key features are scoped to the wallet.
+func (j *JWTAuth) GenerateWalletToken(address string) (string, error) {
+	claims := &Claims{
+		APIKey: address,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   address,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secretKey)
+}
+
+// GenerateAdminToken creates a new JWT token for the given API key that is
+// granted ScopeAdmin
+func (j *JWTAuth) GenerateAdminToken(apiKey string) (string, error) {
+	claims := &Claims{
+		APIKey: apiKey,
+		Scopes: []string{ScopeAdmin},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "admin",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.duration)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secretKey)
+}
+
 // ValidateToken validates the JWT token from the request
 func (j *JWTAuth) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
@@ -92,7 +136,41 @@ func (j *JWTAuth) Middleware(next http.Handler) http.Handler {
 
 		// Add claims to request context
 		ctx := r.Context()
-		ctx = context.WithValue(ctx, "api_key", claims.APIKey)
+		ctx = context.WithValue(ctx, apiKeyContextKey, claims.APIKey)
+		ctx = context.WithValue(ctx, scopesContextKey, claims.Scopes)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequireScope rejects requests whose token was not granted scope. It must
+// run after Middleware.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !HasScope(r.Context(), scope) {
+				http.Error(w, "Insufficient scope", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// HasScope reports whether the token authenticated by Middleware was granted
+// scope
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, _ := ctx.Value(scopesContextKey).([]string)
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyFromContext returns the API key authenticated by Middleware, or an
+// empty string for unauthenticated requests
+func APIKeyFromContext(ctx context.Context) string {
+	apiKey, _ := ctx.Value(apiKeyContextKey).(string)
+	return apiKey
+}
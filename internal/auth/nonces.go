@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NonceStore keeps issued SIWE nonces until they are redeemed or expire.
+// Nonces are kept apart from the cache, so that they are never evicted or
+// purged before they are used.
+type NonceStore interface {
+	Add(ctx context.Context, nonce string, ttl time.Duration) error
+	// Consume removes a nonce and reports whether it was issued and unused.
+	// Of concurrent calls for the same nonce, at most one returns true.
+	Consume(ctx context.Context, nonce string) (bool, error)
+}
+
+// MemoryNonceStore keeps nonces in process, for single-replica deployments
+type MemoryNonceStore struct {
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+func (s *MemoryNonceStore) Add(ctx context.Context, nonce string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// Drop unredeemed nonces at most once per TTL
+	if now.Sub(s.lastSweep) > ttl {
+		for n, expiresAt := range s.nonces {
+			if now.After(expiresAt) {
+				delete(s.nonces, n)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return nil
+}
+
+func (s *MemoryNonceStore) Consume(ctx context.Context, nonce string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.nonces[nonce]
+	if !ok {
+		return false, nil
+	}
+	delete(s.nonces, nonce)
+	return time.Now().Before(expiresAt), nil
+}
+
+// RedisNonceStore shares nonces between replicas
+type RedisNonceStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisNonceStore(client redis.UniversalClient, prefix string) *RedisNonceStore {
+	return &RedisNonceStore{client: client, prefix: prefix}
+}
+
+func (s *RedisNonceStore) Add(ctx context.Context, nonce string, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+nonce, 1, ttl).Err()
+}
+
+// Consume relies on DEL reporting how many keys it removed, so only one
+// caller can see the nonce go
+func (s *RedisNonceStore) Consume(ctx context.Context, nonce string) (bool, error) {
+	deleted, err := s.client.Del(ctx, s.prefix+nonce).Result()
+	if err != nil {
+		return false, err
+	}
+	return deleted == 1, nil
+}
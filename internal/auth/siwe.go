@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/verify"
+)
+
+const (
+	nonceAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	nonceLength   = 17
+	// clockSkew tolerates small differences between wallet and server clocks
+	clockSkew = time.Minute
+)
+
+// SIWEAuth implements Sign-In with Ethereum (EIP-4361). Nonces are single-use
+// and kept in a NonceStore, which replicas share when it is backed by Redis.
+type SIWEAuth struct {
+	domain   string
+	uri      *url.URL
+	nonceTTL time.Duration
+	nonces   NonceStore
+	registry *chain.Registry
+}
+
+// NewSIWEAuth creates a SIWE authenticator accepting messages for the given
+// domain and URI, signed on any chain with a registered validator
+func NewSIWEAuth(domain, uri string, nonceTTL time.Duration, nonces NonceStore, registry *chain.Registry) (*SIWEAuth, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid SIWE URI %q", uri)
+	}
+	return &SIWEAuth{
+		domain:   domain,
+		uri:      parsed,
+		nonceTTL: nonceTTL,
+		nonces:   nonces,
+		registry: registry,
+	}, nil
+}
+
+// IssueNonce creates a new single-use nonce
+func (s *SIWEAuth) IssueNonce(ctx context.Context) (string, time.Time, error) {
+	nonce := make([]byte, nonceLength)
+	for i := range nonce {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(nonceAlphabet))))
+		if err != nil {
+			return "", time.Time{}, fmt.Errorf("failed to generate nonce: %w", err)
+		}
+		nonce[i] = nonceAlphabet[n.Int64()]
+	}
+
+	expiresAt := time.Now().Add(s.nonceTTL)
+	if err := s.nonces.Add(ctx, string(nonce), s.nonceTTL); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store nonce: %w", err)
+	}
+	return string(nonce), expiresAt, nil
+}
+
+// Verify parses a SIWE message, checks its domain, URI, chain ID, validity
+// window, verifies the signature (EOA or EIP-1271) and then consumes the
+// nonce, so a message can only be redeemed once and a forged signature
+// cannot burn another wallet's nonce.
+func (s *SIWEAuth) Verify(ctx context.Context, message string, signature []byte) (*SIWEMessage, error) {
+	msg, err := ParseSIWEMessage(message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SIWE message: %w", err)
+	}
+
+	if msg.Domain != s.domain || (msg.Scheme != "" && msg.Scheme != s.uri.Scheme) {
+		return nil, fmt.Errorf("domain mismatch: expected %s", s.domain)
+	}
+	msgURI, err := url.Parse(msg.URI)
+	if err != nil || msgURI.Scheme != s.uri.Scheme || msgURI.Host != s.uri.Host {
+		return nil, fmt.Errorf("URI mismatch: expected %s", s.uri.String())
+	}
+
+	now := time.Now()
+	if msg.ExpirationTime != nil && now.After(*msg.ExpirationTime) {
+		return nil, fmt.Errorf("message has expired")
+	}
+	if msg.NotBefore != nil && now.Add(clockSkew).Before(*msg.NotBefore) {
+		return nil, fmt.Errorf("message is not yet valid")
+	}
+	if msg.IssuedAt.After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("message issued in the future")
+	}
+
+	v, err := s.validatorForChainID(msg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := verify.NewVerifier(v).Verify(ctx, common.HexToAddress(msg.Address), verify.HashPersonalMessage([]byte(message)), signature)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify signature: %w", err)
+	}
+	if !result.Valid {
+		return nil, fmt.Errorf("signature does not match %s", msg.Address)
+	}
+
+	consumed, err := s.nonces.Consume(ctx, msg.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume nonce: %w", err)
+	}
+	if !consumed {
+		return nil, fmt.Errorf("unknown or already used nonce")
+	}
+	return msg, nil
+}
+
+func (s *SIWEAuth) validatorForChainID(chainID uint64) (chain.Validator, error) {
+	for _, name := range s.registry.ListChains() {
+		v, err := s.registry.Get(name)
+		if err != nil {
+			continue
+		}
+		if v.GetChainID().Uint64() == chainID {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported chain ID %d", chainID)
+}
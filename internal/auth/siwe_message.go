@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator"
+)
+
+const siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+
+var siweNonceRegex = regexp.MustCompile("^[a-zA-Z0-9]{8,}$")
+
+var siweFields = map[string]bool{
+	"URI":             true,
+	"Version":         true,
+	"Chain ID":        true,
+	"Nonce":           true,
+	"Issued At":       true,
+	"Expiration Time": true,
+	"Not Before":      true,
+	"Request ID":      true,
+}
+
+// SIWEMessage is a parsed EIP-4361 Sign-In with Ethereum message
+type SIWEMessage struct {
+	Scheme         string
+	Domain         string
+	Address        string
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        uint64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+// ParseSIWEMessage parses an EIP-4361 message
+func ParseSIWEMessage(message string) (*SIWEMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("message too short")
+	}
+
+	msg := &SIWEMessage{}
+
+	header := lines[0]
+	if !strings.HasSuffix(header, siweHeaderSuffix) {
+		return nil, fmt.Errorf("invalid message header")
+	}
+	msg.Domain = strings.TrimSuffix(header, siweHeaderSuffix)
+	if scheme, domain, ok := strings.Cut(msg.Domain, "://"); ok {
+		msg.Scheme = scheme
+		msg.Domain = domain
+	}
+	if msg.Domain == "" {
+		return nil, fmt.Errorf("missing domain")
+	}
+
+	msg.Address = lines[1]
+	if !validator.IsChecksumAddress(msg.Address) {
+		return nil, fmt.Errorf("address must be an EIP-55 checksummed address")
+	}
+
+	// Blank line, optional statement, blank line
+	i := 2
+	for i < len(lines) && lines[i] == "" {
+		i++
+	}
+	if i < len(lines) && !strings.HasPrefix(lines[i], "URI: ") {
+		msg.Statement = lines[i]
+		i++
+		for i < len(lines) && lines[i] == "" {
+			i++
+		}
+	}
+
+	fields := make(map[string]string)
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+		if line == "Resources:" {
+			for i++; i < len(lines) && strings.HasPrefix(lines[i], "- "); i++ {
+				msg.Resources = append(msg.Resources, strings.TrimPrefix(lines[i], "- "))
+			}
+			if i < len(lines) && strings.TrimSpace(strings.Join(lines[i:], "")) != "" {
+				return nil, fmt.Errorf("unexpected content after resources")
+			}
+			break
+		}
+
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %q", line)
+		}
+		if !siweFields[key] {
+			return nil, fmt.Errorf("unknown field %q", key)
+		}
+		if _, dup := fields[key]; dup {
+			return nil, fmt.Errorf("duplicate field %q", key)
+		}
+		fields[key] = value
+	}
+
+	var err error
+	if msg.URI = fields["URI"]; msg.URI == "" {
+		return nil, fmt.Errorf("missing URI")
+	}
+	if msg.Version = fields["Version"]; msg.Version != "1" {
+		return nil, fmt.Errorf("unsupported version %q", msg.Version)
+	}
+	if msg.ChainID, err = strconv.ParseUint(fields["Chain ID"], 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid chain ID")
+	}
+	if msg.Nonce = fields["Nonce"]; !siweNonceRegex.MatchString(msg.Nonce) {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+	if msg.IssuedAt, err = time.Parse(time.RFC3339, fields["Issued At"]); err != nil {
+		return nil, fmt.Errorf("invalid issued at time")
+	}
+	if v, ok := fields["Expiration Time"]; ok {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiration time")
+		}
+		msg.ExpirationTime = &t
+	}
+	if v, ok := fields["Not Before"]; ok {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid not before time")
+		}
+		msg.NotBefore = &t
+	}
+	msg.RequestID = fields["Request ID"]
+
+	return msg, nil
+}
@@ -0,0 +1,210 @@
+package bytecode
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+//go:embed catalogue.json
+var defaultCatalogue []byte
+
+// Entry describes a known contract in the fingerprint catalogue. An entry
+// matches either on its exact runtime hash (metadata stripped) or on its
+// skeleton hash, which ignores immutables and embedded addresses so that
+// every clone of a template matches. Entries may give reference code instead
+// of hashes, in which case both hashes are computed when the catalogue loads.
+//
+// Contracts whose bytecode varies with the compiler and its settings are
+// described by a profile instead: the function signatures the dispatcher
+// must handle and the 32-byte constants (such as EIP-1967 slots) the code
+// must push. Contracts that share those constants are told apart by the
+// constants a profile rules out, or by requiring that the code has no
+// dispatcher at all, as proxies that only forward calls do. A profile match
+// is never exact.
+type Entry struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Category     string   `json:"category"`
+	Version      string   `json:"version,omitempty"`
+	Source       string   `json:"source,omitempty"`
+	Code         string   `json:"code,omitempty"`
+	RuntimeHash  string   `json:"runtimeHash,omitempty"`
+	SkeletonHash string   `json:"skeletonHash,omitempty"`
+	Selectors    []string `json:"selectors,omitempty"`
+	Constants    []string `json:"constants,omitempty"`
+	// AbsentConstants must not be pushed by the code
+	AbsentConstants []string `json:"absentConstants,omitempty"`
+	// FallbackOnly requires that the code dispatches no function selectors
+	FallbackOnly bool `json:"fallbackOnly,omitempty"`
+}
+
+// Catalogue indexes known contracts by runtime and skeleton hash
+type Catalogue struct {
+	entries    []Entry
+	byRuntime  map[string][]Entry
+	bySkeleton map[string][]Entry
+	profiles   []profile
+}
+
+// profile is an entry matched by the selectors and constants in the code
+type profile struct {
+	entry     Entry
+	selectors [][4]byte
+	constants []common.Hash
+	absent    []common.Hash
+}
+
+type catalogueFile struct {
+	Entries []Entry `json:"entries"`
+}
+
+// LoadCatalogue loads the bundled catalogue and merges entries from the file
+// at path, if given. File entries replace bundled entries with the same ID.
+func LoadCatalogue(path string) (*Catalogue, error) {
+	var bundled catalogueFile
+	if err := json.Unmarshal(defaultCatalogue, &bundled); err != nil {
+		return nil, fmt.Errorf("failed to parse bundled catalogue: %w", err)
+	}
+	entries := bundled.Entries
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read catalogue: %w", err)
+		}
+		var local catalogueFile
+		if err := json.Unmarshal(data, &local); err != nil {
+			return nil, fmt.Errorf("failed to parse catalogue %s: %w", path, err)
+		}
+		entries = mergeEntries(entries, local.Entries)
+	}
+
+	return newCatalogue(entries)
+}
+
+func newCatalogue(entries []Entry) (*Catalogue, error) {
+	c := &Catalogue{
+		byRuntime:  make(map[string][]Entry),
+		bySkeleton: make(map[string][]Entry),
+	}
+
+	for _, e := range entries {
+		if e.ID == "" {
+			return nil, fmt.Errorf("catalogue entry without id")
+		}
+		if e.Code != "" {
+			code, err := decodeHex(e.Code)
+			if err != nil {
+				return nil, fmt.Errorf("invalid code for catalogue entry %s: %w", e.ID, err)
+			}
+			runtime, _ := SplitMetadata(code)
+			e.RuntimeHash = hashHex(runtime)
+			e.SkeletonHash = hashHex(skeleton(runtime))
+			e.Code = ""
+		}
+		if len(e.Selectors) > 0 || len(e.Constants) > 0 || len(e.AbsentConstants) > 0 || e.FallbackOnly {
+			p, err := newProfile(e)
+			if err != nil {
+				return nil, err
+			}
+			c.profiles = append(c.profiles, p)
+		} else if e.RuntimeHash == "" && e.SkeletonHash == "" {
+			return nil, fmt.Errorf("catalogue entry %s has no code, hash or profile", e.ID)
+		}
+
+		e.RuntimeHash = strings.ToLower(e.RuntimeHash)
+		e.SkeletonHash = strings.ToLower(e.SkeletonHash)
+		if e.RuntimeHash != "" {
+			c.byRuntime[e.RuntimeHash] = append(c.byRuntime[e.RuntimeHash], e)
+		}
+		if e.SkeletonHash != "" {
+			c.bySkeleton[e.SkeletonHash] = append(c.bySkeleton[e.SkeletonHash], e)
+		}
+		c.entries = append(c.entries, e)
+	}
+	return c, nil
+}
+
+func newProfile(e Entry) (profile, error) {
+	p := profile{entry: e}
+	for _, sig := range e.Selectors {
+		if !strings.Contains(sig, "(") || !strings.HasSuffix(sig, ")") {
+			return profile{}, fmt.Errorf("invalid selector %q in catalogue entry %s: expected a function signature", sig, e.ID)
+		}
+		var sel [4]byte
+		copy(sel[:], crypto.Keccak256([]byte(sig)))
+		p.selectors = append(p.selectors, sel)
+	}
+	var err error
+	if p.constants, err = parseConstants(e.ID, e.Constants); err != nil {
+		return profile{}, err
+	}
+	if p.absent, err = parseConstants(e.ID, e.AbsentConstants); err != nil {
+		return profile{}, err
+	}
+	return p, nil
+}
+
+func parseConstants(id string, constants []string) ([]common.Hash, error) {
+	hashes := make([]common.Hash, 0, len(constants))
+	for _, constant := range constants {
+		value, err := decodeHex(constant)
+		if err != nil || len(value) != common.HashLength {
+			return nil, fmt.Errorf("invalid constant %q in catalogue entry %s: expected 32 bytes", constant, id)
+		}
+		hashes = append(hashes, common.BytesToHash(value))
+	}
+	return hashes, nil
+}
+
+// matches reports whether the code dispatches every selector and pushes
+// every constant of the profile, and none of the constants it rules out
+func (p profile) matches(selectors map[[4]byte]bool, constants map[common.Hash]bool) bool {
+	if p.entry.FallbackOnly && len(selectors) > 0 {
+		return false
+	}
+	for _, constant := range p.absent {
+		if constants[constant] {
+			return false
+		}
+	}
+	for _, sel := range p.selectors {
+		if !selectors[sel] {
+			return false
+		}
+	}
+	for _, constant := range p.constants {
+		if !constants[constant] {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of entries in the catalogue
+func (c *Catalogue) Len() int {
+	return len(c.entries)
+}
+
+func mergeEntries(base, overrides []Entry) []Entry {
+	index := make(map[string]int, len(base))
+	merged := append([]Entry(nil), base...)
+	for i, e := range merged {
+		index[e.ID] = i
+	}
+	for _, e := range overrides {
+		if i, ok := index[e.ID]; ok {
+			merged[i] = e
+			continue
+		}
+		index[e.ID] = len(merged)
+		merged = append(merged, e)
+	}
+	return merged
+}
@@ -0,0 +1,89 @@
+package bytecode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("production")
+	os.Exit(m.Run())
+}
+
+// EIP-1967 storage slots
+var (
+	implementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	adminSlot          = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+)
+
+// testCode assembles runtime code with a dispatcher for the given function
+// signatures, followed by a delegatecall through each storage slot
+func testCode(signatures []string, slots ...common.Hash) []byte {
+	// PUSH1 0 CALLDATALOAD PUSH1 0xe0 SHR
+	code := []byte{0x60, 0x00, 0x35, 0x60, 0xe0, 0x1c}
+	for _, sig := range signatures {
+		// DUP1 PUSH4 selector EQ PUSH2 0 JUMPI
+		code = append(code, 0x80, 0x63)
+		code = append(code, crypto.Keccak256([]byte(sig))[:4]...)
+		code = append(code, 0x14, 0x61, 0x00, 0x00, 0x57)
+	}
+	for _, slot := range slots {
+		// PUSH32 slot SLOAD GAS DELEGATECALL
+		code = append(code, 0x7f)
+		code = append(code, slot.Bytes()...)
+		code = append(code, 0x54, 0x5a, 0xf4)
+	}
+	return append(code, 0x00)
+}
+
+func matchIDs(fp *Fingerprint) map[string]bool {
+	ids := make(map[string]bool)
+	for _, m := range fp.Matches {
+		ids[m.ID] = true
+	}
+	return ids
+}
+
+func TestProxyProfiles(t *testing.T) {
+	s, err := NewService("")
+	if err != nil {
+		t.Fatalf("NewService: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		code []byte
+		want map[string]bool
+	}{
+		{
+			name: "ERC1967Proxy",
+			code: testCode(nil, implementationSlot),
+			want: map[string]bool{"oz-erc1967-proxy": true, "oz-transparent-upgradeable-proxy": false},
+		},
+		{
+			name: "UUPS implementation",
+			code: testCode([]string{"proxiableUUID()", "upgradeTo(address)", "upgradeToAndCall(address,bytes)", "owner()"}, implementationSlot),
+			want: map[string]bool{"oz-erc1967-proxy": false, "oz-transparent-upgradeable-proxy": false},
+		},
+		{
+			name: "TransparentUpgradeableProxy",
+			code: testCode(nil, implementationSlot, adminSlot),
+			want: map[string]bool{"oz-erc1967-proxy": false, "oz-transparent-upgradeable-proxy": true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids := matchIDs(s.Fingerprint(tt.code))
+			for id, want := range tt.want {
+				if ids[id] != want {
+					t.Errorf("matched %s = %v, want %v", id, ids[id], want)
+				}
+			}
+		})
+	}
+}
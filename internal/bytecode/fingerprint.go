@@ -0,0 +1,169 @@
+package bytecode
+
+import (
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/reload"
+	"go.uber.org/zap"
+)
+
+// EIP-7702 delegation designator prefix: 0xef0100 ++ address
+var delegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// Match is a catalogue entry matched by a fingerprint
+type Match struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Version  string `json:"version,omitempty"`
+	Source   string `json:"source,omitempty"`
+	// Exact is true when the runtime code matched byte for byte, false when
+	// only the skeleton (code without immutables and embedded addresses) or
+	// the entry's profile did
+	Exact bool `json:"exact"`
+}
+
+// Fingerprint identifies a piece of runtime bytecode
+type Fingerprint struct {
+	Size         int       `json:"size"`
+	CodeHash     string    `json:"codeHash"`
+	RuntimeHash  string    `json:"runtimeHash"`
+	SkeletonHash string    `json:"skeletonHash"`
+	Metadata     *Metadata `json:"metadata,omitempty"`
+	Delegation   string    `json:"delegation,omitempty"`
+	Matches      []Match   `json:"matches,omitempty"`
+}
+
+// Service fingerprints bytecode against a catalogue that is reloaded
+// whenever its backing file changes
+type Service struct {
+	path      string
+	file      *reload.File
+	mu        sync.RWMutex
+	catalogue *Catalogue
+}
+
+// NewService creates a fingerprint service. If path is empty only the bundled
+// catalogue is used.
+func NewService(path string) (*Service, error) {
+	s := &Service{path: path}
+	file, err := reload.NewFile(path, s.load)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+// Reload re-reads the catalogue from disk
+func (s *Service) Reload() error {
+	return s.file.Reload()
+}
+
+func (s *Service) load() error {
+	catalogue, err := LoadCatalogue(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.catalogue = catalogue
+	s.mu.Unlock()
+
+	logger.Info("Loaded bytecode catalogue",
+		zap.String("path", s.path),
+		zap.Int("entries", catalogue.Len()))
+	return nil
+}
+
+// Fingerprint hashes the given runtime code and matches it against the catalogue
+func (s *Service) Fingerprint(code []byte) *Fingerprint {
+	s.file.Check()
+
+	fp := &Fingerprint{
+		Size:     len(code),
+		CodeHash: hashHex(code),
+	}
+
+	if target, ok := DelegationTarget(code); ok {
+		fp.Delegation = target.Hex()
+	}
+
+	runtime, meta := SplitMetadata(code)
+	fp.Metadata = meta
+	fp.RuntimeHash = hashHex(runtime)
+	fp.SkeletonHash = hashHex(skeleton(runtime))
+
+	s.mu.RLock()
+	catalogue := s.catalogue
+	s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	for _, e := range catalogue.byRuntime[fp.RuntimeHash] {
+		seen[e.ID] = true
+		fp.Matches = append(fp.Matches, newMatch(e, true))
+	}
+	for _, e := range catalogue.bySkeleton[fp.SkeletonHash] {
+		if !seen[e.ID] {
+			seen[e.ID] = true
+			fp.Matches = append(fp.Matches, newMatch(e, false))
+		}
+	}
+	if len(catalogue.profiles) > 0 {
+		selectors := make(map[[4]byte]bool)
+		for _, sel := range FunctionSelectors(runtime) {
+			selectors[sel] = true
+		}
+		constants := make(map[common.Hash]bool)
+		for _, value := range Push32Values(runtime) {
+			constants[value] = true
+		}
+		for _, p := range catalogue.profiles {
+			if !seen[p.entry.ID] && p.matches(selectors, constants) {
+				fp.Matches = append(fp.Matches, newMatch(p.entry, false))
+			}
+		}
+	}
+	return fp
+}
+
+func newMatch(e Entry, exact bool) Match {
+	return Match{
+		ID:       e.ID,
+		Name:     e.Name,
+		Category: e.Category,
+		Version:  e.Version,
+		Source:   e.Source,
+		Exact:    exact,
+	}
+}
+
+// skeleton returns a copy of the code with every PUSH20..PUSH32 operand
+// zeroed. Solidity immutables and hard-coded addresses live in those
+// operands, so clones of the same template share a skeleton.
+func skeleton(code []byte) []byte {
+	out := make([]byte, len(code))
+	copy(out, code)
+	for _, ins := range Disassemble(code) {
+		if ins.Op >= opPUSH20 && ins.Op <= opPUSH32 {
+			start := ins.PC + 1
+			for i := start; i < start+len(ins.Arg); i++ {
+				out[i] = 0
+			}
+		}
+	}
+	return out
+}
+
+func hashHex(data []byte) string {
+	return crypto.Keccak256Hash(data).Hex()
+}
+
+func decodeHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimPrefix(s, "0x"))
+}
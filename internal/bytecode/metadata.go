@@ -0,0 +1,151 @@
+package bytecode
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Metadata is the compiler metadata appended to runtime bytecode as CBOR
+type Metadata struct {
+	Compiler     string `json:"compiler"`
+	Version      string `json:"version,omitempty"`
+	IPFS         string `json:"ipfs,omitempty"`
+	Swarm        string `json:"swarm,omitempty"`
+	Experimental bool   `json:"experimental,omitempty"`
+}
+
+// SplitMetadata separates the CBOR metadata trailer from runtime bytecode.
+// Solidity and Vyper both end the code with a CBOR item followed by its
+// two-byte big-endian length (Vyper >= 0.3.10 counts the length bytes too).
+// If no metadata is found the code is returned unchanged with a nil Metadata.
+func SplitMetadata(code []byte) ([]byte, *Metadata) {
+	if len(code) < 2 {
+		return code, nil
+	}
+
+	length := int(binary.BigEndian.Uint16(code[len(code)-2:]))
+	for _, size := range []int{length, length - 2} {
+		if size <= 0 || size+2 > len(code) {
+			continue
+		}
+		start := len(code) - 2 - size
+		value, err := decodeCBOR(code[start : len(code)-2])
+		if err != nil {
+			continue
+		}
+		if meta := parseMetadata(value); meta != nil {
+			return code[:start], meta
+		}
+	}
+	return code, nil
+}
+
+func parseMetadata(value interface{}) *Metadata {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return parseMetadataMap(v)
+	case []interface{}:
+		// Vyper >= 0.3.10 emits [runtime size, data sizes, immutables size, {"vyper": [...]}]
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				if meta := parseMetadataMap(m); meta != nil {
+					return meta
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func parseMetadataMap(m map[string]interface{}) *Metadata {
+	meta := &Metadata{}
+	found := false
+
+	if v, ok := m["solc"]; ok {
+		meta.Compiler = "solc"
+		meta.Version = versionString(v)
+		found = true
+	}
+	if v, ok := m["vyper"]; ok {
+		meta.Compiler = "vyper"
+		meta.Version = versionString(v)
+		found = true
+	}
+	if v, ok := m["ipfs"].([]byte); ok {
+		meta.IPFS = base58Encode(v)
+		found = true
+	}
+	for _, key := range []string{"bzzr1", "bzzr0"} {
+		if v, ok := m[key].([]byte); ok {
+			meta.Swarm = key + "://" + hex.EncodeToString(v)
+			found = true
+			break
+		}
+	}
+	if v, ok := m["experimental"].(bool); ok {
+		meta.Experimental = v
+	}
+
+	if !found {
+		return nil
+	}
+	if meta.Compiler == "" {
+		// solc only started embedding its version in 0.5.9; older
+		// releases only carry the source hash
+		meta.Compiler = "solc"
+	}
+	return meta
+}
+
+// versionString formats a compiler version encoded either as raw bytes
+// (solc release builds), a string (solc prereleases) or an integer array (Vyper)
+func versionString(v interface{}) string {
+	switch version := v.(type) {
+	case []byte:
+		parts := make([]string, len(version))
+		for i, b := range version {
+			parts[i] = fmt.Sprintf("%d", b)
+		}
+		return strings.Join(parts, ".")
+	case string:
+		return version
+	case []interface{}:
+		parts := make([]string, 0, len(version))
+		for _, p := range version {
+			if n, ok := p.(uint64); ok {
+				parts = append(parts, fmt.Sprintf("%d", n))
+			}
+		}
+		return strings.Join(parts, ".")
+	default:
+		return ""
+	}
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Encode renders an IPFS multihash as a CIDv0 string
+func base58Encode(data []byte) string {
+	n := new(big.Int).SetBytes(data)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
@@ -0,0 +1,56 @@
+package bytecode
+
+// Opcodes referenced by the analyzers
+const (
+	opEQ     byte = 0x14
+	opXOR    byte = 0x18
+	opPUSH0  byte = 0x5f
+	opPUSH1  byte = 0x60
+	opPUSH4  byte = 0x63
+	opPUSH20 byte = 0x73
+	opPUSH32 byte = 0x7f
+	opDUP1   byte = 0x80
+	opDUP16  byte = 0x8f
+)
+
+// Instruction is a single decoded EVM instruction
+type Instruction struct {
+	PC  int
+	Op  byte
+	Arg []byte
+}
+
+// Disassemble decodes EVM bytecode into instructions. Truncated PUSH data at
+// the end of the code is returned as a shorter argument.
+func Disassemble(code []byte) []Instruction {
+	instructions := make([]Instruction, 0, len(code))
+	for pc := 0; pc < len(code); {
+		op := code[pc]
+		ins := Instruction{PC: pc, Op: op}
+
+		if n := pushSize(op); n > 0 {
+			end := pc + 1 + n
+			if end > len(code) {
+				end = len(code)
+			}
+			ins.Arg = code[pc+1 : end]
+			pc = end
+		} else {
+			pc++
+		}
+		instructions = append(instructions, ins)
+	}
+	return instructions
+}
+
+// pushSize returns the number of immediate bytes for PUSH1..PUSH32, or 0
+func pushSize(op byte) int {
+	if op >= opPUSH1 && op <= opPUSH32 {
+		return int(op-opPUSH1) + 1
+	}
+	return 0
+}
+
+func isDup(op byte) bool {
+	return op >= opDUP1 && op <= opDUP16
+}
@@ -0,0 +1,40 @@
+package bytecode
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Minimal proxy templates; the implementation address follows the prefix
+var minimalProxies = []struct {
+	prefix []byte
+	suffix []byte
+}{
+	// EIP-1167
+	{common.FromHex("0x363d3d373d3d3d363d73"), common.FromHex("0x5af43d82803e903d91602b57fd5bf3")},
+	// EIP-7511 (PUSH0)
+	{common.FromHex("0x365f5f375f5f365f73"), common.FromHex("0x5af43d5f5f3e5f3d91602a57fd5bf3")},
+}
+
+// DelegationTarget returns the address an EOA delegates to when its code is
+// an EIP-7702 delegation designator (0xef0100 ++ address)
+func DelegationTarget(code []byte) (common.Address, bool) {
+	if len(code) == 23 && bytes.HasPrefix(code, delegationPrefix) {
+		return common.BytesToAddress(code[3:]), true
+	}
+	return common.Address{}, false
+}
+
+// MinimalProxyTarget returns the implementation of an EIP-1167 or EIP-7511
+// minimal proxy
+func MinimalProxyTarget(code []byte) (common.Address, bool) {
+	for _, p := range minimalProxies {
+		if len(code) == len(p.prefix)+20+len(p.suffix) &&
+			bytes.HasPrefix(code, p.prefix) &&
+			bytes.HasSuffix(code, p.suffix) {
+			return common.BytesToAddress(code[len(p.prefix) : len(p.prefix)+20]), true
+		}
+	}
+	return common.Address{}, false
+}
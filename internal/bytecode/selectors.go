@@ -0,0 +1,57 @@
+package bytecode
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FunctionSelectors extracts the function selectors compared against the
+// calldata in a contract's dispatcher. Solidity and Vyper dispatchers push
+// each selector with PUSH4 and compare it with EQ (or XOR), optionally after
+// a DUP; PUSH4 operands used for binary-search splits (GT/LT) or masks are
+// ignored. The metadata trailer should be stripped beforehand.
+func FunctionSelectors(code []byte) [][4]byte {
+	instructions := Disassemble(code)
+	seen := make(map[[4]byte]bool)
+	var selectors [][4]byte
+
+	for i, ins := range instructions {
+		if ins.Op != opPUSH4 || len(ins.Arg) != 4 {
+			continue
+		}
+
+		j := i + 1
+		for j < len(instructions) && isDup(instructions[j].Op) {
+			j++
+		}
+		if j >= len(instructions) || (instructions[j].Op != opEQ && instructions[j].Op != opXOR) {
+			continue
+		}
+
+		var sel [4]byte
+		copy(sel[:], ins.Arg)
+		if sel == [4]byte{0xff, 0xff, 0xff, 0xff} || seen[sel] {
+			continue
+		}
+		seen[sel] = true
+		selectors = append(selectors, sel)
+	}
+	return selectors
+}
+
+// Push32Values returns the distinct PUSH32 operands in the code. Event
+// topics are emitted this way, so they are candidates for topic lookup.
+func Push32Values(code []byte) []common.Hash {
+	seen := make(map[common.Hash]bool)
+	var values []common.Hash
+	for _, ins := range Disassemble(code) {
+		if ins.Op != opPUSH32 || len(ins.Arg) != 32 {
+			continue
+		}
+		h := common.BytesToHash(ins.Arg)
+		if !seen[h] {
+			seen[h] = true
+			values = append(values, h)
+		}
+	}
+	return values
+}
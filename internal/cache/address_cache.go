@@ -3,13 +3,63 @@ package cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"golang.org/x/sync/singleflight"
+
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/types"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/head"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/tokens"
 )
 
+// AddressCache stores typed lookup results in a Cache, each data type with
+// its own TTL
 type AddressCache struct {
-	cache Cache
+	cache      Cache
+	ttls       TTLs
+	refreshing sync.Map
+	// inflight coalesces concurrent loads of the same key
+	inflight    singleflight.Group
+	locker      Locker
+	lockTimeout time.Duration
+	// namespaces holds a *namespaceCounter per key namespace
+	namespaces sync.Map
+	heads      *head.Tracker
+	headExpiry uint64
+}
+
+// NamespaceStats counts the cache hits and misses of one kind of lookup. The
+// namespace is the part of the key before the first colon, e.g. ens or
+// contract.
+type NamespaceStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type namespaceCounter struct {
+	hits   uint64
+	misses uint64
+}
+
+// TTLs sets how long each data type is cached. Zero values use defaultTTL.
+type TTLs struct {
+	ENS           time.Duration
+	ReverseENS    time.Duration
+	Contract      time.Duration
+	TokenMetadata time.Duration
+	// Negative is how long not-found results are cached; zero disables
+	// negative caching
+	Negative time.Duration
+	// StaleWhileRevalidate is how long past its TTL a lookup is served while
+	// it is refreshed in the background
+	StaleWhileRevalidate time.Duration
+	// StaleIfError is how long past its TTL a lookup is served when the node
+	// fails
+	StaleIfError time.Duration
 }
 
 type AddressInfo struct {
@@ -20,55 +70,165 @@ type AddressInfo struct {
 
 const defaultTTL = 1 * time.Hour
 
-func NewAddressCache(cache Cache) *AddressCache {
+// ensChain is the chain ENS records are read from
+const ensChain = "ethereum"
+
+func NewAddressCache(cache Cache, ttls TTLs) *AddressCache {
+	for _, ttl := range []*time.Duration{&ttls.ENS, &ttls.ReverseENS, &ttls.Contract, &ttls.TokenMetadata} {
+		if *ttl <= 0 {
+			*ttl = defaultTTL
+		}
+	}
 	return &AddressCache{
 		cache: cache,
+		ttls:  ttls,
 	}
 }
 
-func (ac *AddressCache) GetAddressInfo(address string) (*AddressInfo, error) {
-	data, err := ac.cache.Get(context.Background(), "addr:"+address)
-	if err != nil {
-		return nil, err
-	}
-	if data == nil {
-		return nil, nil
-	}
+// SetLocker makes replicas sharing the cache coalesce their loads: one
+// replica loads a missing entry while the others wait up to timeout for it
+func (ac *AddressCache) SetLocker(locker Locker, timeout time.Duration) {
+	ac.locker = locker
+	ac.lockTimeout = timeout
+}
 
+func (ac *AddressCache) GetAddressInfo(ctx context.Context, address string) (*AddressInfo, error) {
 	var info AddressInfo
-	if err := json.Unmarshal(data, &info); err != nil {
+	found, err := ac.getJSON(ctx, "addr:"+address, &info)
+	ac.count("addr:", found)
+	if err != nil || !found {
 		return nil, err
 	}
 	return &info, nil
 }
 
-func (ac *AddressCache) SetAddressInfo(address string, info *AddressInfo) error {
-	data, err := json.Marshal(info)
-	if err != nil {
-		return err
+func (ac *AddressCache) SetAddressInfo(ctx context.Context, address string, info *AddressInfo) error {
+	return ac.setJSON(ctx, "addr:"+address, info, defaultTTL)
+}
+
+// FetchENSAddress returns the address of an ENS name, calling resolve if it
+// is not cached. Names without an address are cached as a NotFoundError.
+func (ac *AddressCache) FetchENSAddress(ctx context.Context, name string, resolve func(context.Context) (string, error)) (string, error) {
+	var address string
+	err := ac.fetch(ctx, ensChain, "ens:"+name, ac.ttls.ENS, &address, func(ctx context.Context) (interface{}, error) {
+		return resolve(ctx)
+	})
+	return address, err
+}
+
+// FetchENSName returns the primary name of an address, calling lookup if it
+// is not cached. An empty name means the address has no primary name.
+func (ac *AddressCache) FetchENSName(ctx context.Context, address string, lookup func(context.Context) (string, error)) (string, error) {
+	var name string
+	err := ac.fetch(ctx, ensChain, "ens-reverse:"+strings.ToLower(address), ac.ttls.ReverseENS, &name, func(ctx context.Context) (interface{}, error) {
+		return lookup(ctx)
+	})
+	return name, err
+}
+
+// FetchIsContract returns the contract classification of an address, calling
+// check if it is not cached
+func (ac *AddressCache) FetchIsContract(ctx context.Context, chain, address string, check func(context.Context) (bool, error)) (bool, error) {
+	var isContract bool
+	err := ac.fetch(ctx, chain, "contract:"+chain+":"+strings.ToLower(address), ac.ttls.Contract, &isContract, func(ctx context.Context) (interface{}, error) {
+		return check(ctx)
+	})
+	return isContract, err
+}
+
+// CachedTokenMetadata returns the cached symbol and decimals of a token, or
+// nil if they are not cached or no longer fresh. Tokens cached as not
+// answering decimals() return tokens.ErrNoDecimals.
+func (ac *AddressCache) CachedTokenMetadata(ctx context.Context, chain, token string) (*tokens.Metadata, error) {
+	var metadata tokens.Metadata
+	found, err := ac.lookup(ctx, chain, tokenMetadataKey(chain, token), &metadata)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, tokens.ErrNoDecimals
+	}
+	if err != nil || !found {
+		return nil, err
 	}
-	return ac.cache.Set(context.Background(), "addr:"+address, data, defaultTTL)
+	return &metadata, nil
 }
 
-func (ac *AddressCache) GetENSAddress(name string) (string, error) {
-	data, err := ac.cache.Get(context.Background(), "ens:"+name)
-	if err != nil {
-		return "", err
+// PinHead pins the reads made with ctx to the chain's tracked head, if any,
+// so that the metadata they return is stored tagged with that block
+func (ac *AddressCache) PinHead(ctx context.Context, chain string) context.Context {
+	if block := ac.headBlock(chain); block != nil {
+		return head.WithBlock(ctx, *block)
 	}
-	if data == nil {
-		return "", nil
+	return ctx
+}
+
+// StoreTokenMetadata caches metadata read with ctx, tagged with the block
+// ctx is pinned to. Metadata without decimals is cached as not found.
+func (ac *AddressCache) StoreTokenMetadata(ctx context.Context, chain string, metadata *tokens.Metadata) {
+	var block *head.Block
+	if pinned, ok := head.BlockFromContext(ctx); ok {
+		block = &pinned
+	}
+	var err error
+	if metadata.DecimalsUnknown {
+		err = &NotFoundError{Message: tokens.ErrNoDecimals.Error()}
 	}
-	return string(data), nil
+	ac.put(ctx, tokenMetadataKey(chain, metadata.Token), ac.ttls.TokenMetadata, block, metadata, err)
 }
 
-func (ac *AddressCache) SetENSAddress(name, address string) error {
-	return ac.cache.Set(context.Background(), "ens:"+name, []byte(address), defaultTTL)
+func tokenMetadataKey(chain, token string) string {
+	return "token:" + chain + ":" + strings.ToLower(token)
 }
 
-func (ac *AddressCache) Clear() error {
-	return ac.cache.Clear(context.Background())
+func (ac *AddressCache) Clear(ctx context.Context) error {
+	return ac.cache.Clear(ctx)
 }
 
 func (ac *AddressCache) GetStats() types.Stats {
 	return ac.cache.GetStats()
 }
+
+// NamespaceStats returns the hits and misses of each namespace looked up
+// since startup
+func (ac *AddressCache) NamespaceStats() map[string]NamespaceStats {
+	stats := make(map[string]NamespaceStats)
+	ac.namespaces.Range(func(name, value interface{}) bool {
+		counter := value.(*namespaceCounter)
+		stats[name.(string)] = NamespaceStats{
+			Hits:   atomic.LoadUint64(&counter.hits),
+			Misses: atomic.LoadUint64(&counter.misses),
+		}
+		return true
+	})
+	return stats
+}
+
+// count records a lookup of key as a hit or a miss of its namespace
+func (ac *AddressCache) count(key string, hit bool) {
+	name, _, _ := strings.Cut(key, ":")
+	value, _ := ac.namespaces.LoadOrStore(name, &namespaceCounter{})
+	counter := value.(*namespaceCounter)
+	if hit {
+		atomic.AddUint64(&counter.hits, 1)
+	} else {
+		atomic.AddUint64(&counter.misses, 1)
+	}
+}
+
+func (ac *AddressCache) getJSON(ctx context.Context, key string, value interface{}) (bool, error) {
+	data, err := ac.cache.Get(ctx, key)
+	if err != nil || data == nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (ac *AddressCache) setJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return ac.cache.Set(ctx, key, data, ttl)
+}
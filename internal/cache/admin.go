@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/head"
+)
+
+// ErrAdminUnsupported is returned when the cache backend cannot be inspected
+// or purged by pattern
+var ErrAdminUnsupported = errors.New("the cache backend does not support inspection")
+
+// Entry is a cache entry as shown by the admin API
+type Entry struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value,omitempty"`
+	// TTL is the time left before the backend drops the entry; zero means
+	// it does not expire
+	TTL time.Duration `json:"-"`
+	// NotFound holds the cached error of a negative entry
+	NotFound string `json:"notFound,omitempty"`
+	// StoredAt and Expires are set for fetched entries, which are served
+	// stale between Expires and the end of their TTL
+	StoredAt *time.Time `json:"storedAt,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	// Block is the block the entry was computed at
+	Block *head.Block `json:"block,omitempty"`
+}
+
+// Inspect returns the entry stored under key, or nil if there is none
+func (ac *AddressCache) Inspect(ctx context.Context, key string) (*Entry, error) {
+	admin, ok := ac.cache.(Admin)
+	if !ok {
+		return nil, ErrAdminUnsupported
+	}
+	data, ttl, err := admin.GetWithTTL(ctx, key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	entry := &Entry{Key: key, TTL: ttl}
+	var cached envelope
+	if err := json.Unmarshal(data, &cached); err == nil && !cached.StoredAt.IsZero() {
+		entry.Value = cached.Value
+		entry.NotFound = cached.NotFound
+		entry.StoredAt = &cached.StoredAt
+		entry.Expires = &cached.Expires
+		entry.Block = cached.Block
+		return entry, nil
+	}
+	if json.Valid(data) {
+		entry.Value = data
+	} else {
+		// Shown as a JSON string
+		entry.Value, _ = json.Marshal(string(data))
+	}
+	return entry, nil
+}
+
+// Purge removes the entry stored under key
+func (ac *AddressCache) Purge(ctx context.Context, key string) error {
+	return ac.cache.Delete(ctx, key)
+}
+
+// PurgeMatching removes the entries whose keys match a glob pattern, such as
+// ens:*.uni.eth, and returns how many were removed
+func (ac *AddressCache) PurgeMatching(ctx context.Context, pattern string) (int, error) {
+	admin, ok := ac.cache.(Admin)
+	if !ok {
+		return 0, ErrAdminUnsupported
+	}
+	return admin.DeleteMatching(ctx, pattern)
+}
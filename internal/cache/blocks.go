@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/head"
+)
+
+// SetHeadTracker tags loaded entries with the head of their chain and pins
+// their lookups to it. Entries computed at a block that was reorged away are
+// dropped. Entries whose block is not yet finalized expire once the head is
+// expiryBlocks past it; zero leaves them to their TTL. Entries at or below
+// the finalized block are immutable and only expire with their TTL.
+func (ac *AddressCache) SetHeadTracker(tracker *head.Tracker, expiryBlocks uint64) {
+	ac.heads = tracker
+	ac.headExpiry = expiryBlocks
+}
+
+// headBlock returns the tracked head of chain, or nil if it is not tracked
+func (ac *AddressCache) headBlock(chain string) *head.Block {
+	if ac.heads == nil {
+		return nil
+	}
+	block, ok := ac.heads.Head(chain)
+	if !ok {
+		return nil
+	}
+	return &block
+}
+
+// checkBlock reports whether a cached entry was computed at a block that was
+// reorged away, or at a non-final block the head has since moved past by the
+// expiry distance. Untagged entries are neither.
+func (ac *AddressCache) checkBlock(chain string, e *envelope) (reorged, superseded bool) {
+	if ac.heads == nil || e.Block == nil {
+		return false, false
+	}
+	switch ac.heads.Status(chain, *e.Block) {
+	case head.StatusReorged:
+		return true, false
+	case head.StatusFinalized:
+		return false, false
+	}
+	if ac.headExpiry == 0 {
+		return false, false
+	}
+	current, ok := ac.heads.Head(chain)
+	return false, ok && current.Number >= e.Block.Number+ac.headExpiry
+}
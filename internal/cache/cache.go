@@ -27,3 +27,23 @@ type Cache interface {
 	// GetStats returns cache statistics
 	GetStats() types.Stats
 }
+
+// Locker is implemented by caches shared between replicas, letting one
+// replica load a missing entry while the others wait for it
+type Locker interface {
+	// TryLock takes the lock on key for at most ttl. It returns false
+	// without blocking if another holder has it.
+	TryLock(ctx context.Context, key string, ttl time.Duration) (unlock func(), acquired bool, err error)
+}
+
+// Admin is implemented by caches whose entries can be inspected and purged by
+// pattern through the admin API
+type Admin interface {
+	// GetWithTTL returns a value with its remaining TTL, which is zero for
+	// values that do not expire
+	GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error)
+
+	// DeleteMatching removes the keys matching a glob pattern, as accepted
+	// by path.Match, and returns how many were removed
+	DeleteMatching(ctx context.Context, pattern string) (int, error)
+}
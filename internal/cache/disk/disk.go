@@ -0,0 +1,506 @@
+package disk
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/types"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+)
+
+const (
+	logName     = "cache.log"
+	compactName = "cache.log.compact"
+	// lockName is locked for as long as a process uses the directory. It is
+	// separate from the log, which compaction replaces.
+	lockName = "cache.lock"
+
+	defaultSyncInterval    = time.Second
+	defaultCleanupInterval = time.Minute
+	// The log is compacted once at least half of it, and at least
+	// minCompactBytes, is overwritten, deleted or expired data
+	minCompactBytes = 1 << 20
+)
+
+// errLocked is returned when another process already uses the directory,
+// for example during a rolling restart
+var errLocked = errors.New("disk cache directory is in use by another process")
+
+type Config struct {
+	// Dir holds the log; it is created if missing
+	Dir string
+	// SyncInterval is how often writes are flushed to stable storage. A
+	// crash loses at most the writes of the last interval.
+	SyncInterval time.Duration
+	// CleanupInterval is how often expired entries are dropped and the log
+	// is checked for compaction
+	CleanupInterval time.Duration
+}
+
+// location is where an entry's value is in the log
+type location struct {
+	offset  int64
+	length  int
+	expires int64
+	// size is the size of the whole record, counted as garbage once the
+	// entry is replaced
+	size int64
+}
+
+func (l location) expired(now int64) bool {
+	return l.expires != 0 && now > l.expires
+}
+
+// DiskCache is a cache persisted in an append-only log, so that it is warm
+// after a restart. An in-memory index maps each key to its value in the log.
+// Records torn by a crash are discarded on startup, and the log is compacted
+// once it holds mostly stale data.
+type DiskCache struct {
+	mu      sync.RWMutex
+	dir     string
+	file    *os.File
+	lock    *os.File
+	index   map[string]location
+	size    int64
+	garbage int64
+	dirty   bool
+	stats   types.Stats
+	stop    chan struct{}
+	done    chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+func NewDiskCache(cfg Config) (*DiskCache, error) {
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("a directory is required for the disk cache")
+	}
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = defaultSyncInterval
+	}
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = defaultCleanupInterval
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	lock, err := os.OpenFile(filepath.Join(cfg.Dir, lockName), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open disk cache lock: %w", err)
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	// A compaction interrupted by a crash leaves the old log intact
+	if err := os.Remove(filepath.Join(cfg.Dir, compactName)); err != nil && !os.IsNotExist(err) {
+		lock.Close()
+		return nil, err
+	}
+
+	c := &DiskCache{
+		dir:   cfg.Dir,
+		lock:  lock,
+		index: make(map[string]location),
+		stats: types.Stats{},
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if err := c.open(); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	if err := c.recover(); err != nil {
+		c.file.Close()
+		lock.Close()
+		return nil, err
+	}
+
+	go c.maintain(cfg.SyncInterval, cfg.CleanupInterval)
+
+	return c, nil
+}
+
+func (c *DiskCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	loc, ok := c.index[key]
+	if !ok || loc.expired(time.Now().UnixNano()) {
+		atomic.AddUint64(&c.stats.Misses, 1)
+		return nil, nil
+	}
+	value := make([]byte, loc.length)
+	if _, err := c.file.ReadAt(value, loc.offset); err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	atomic.AddUint64(&c.stats.Hits, 1)
+	return value, nil
+}
+
+// GetWithTTL returns a value with its remaining TTL
+func (c *DiskCache) GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now().UnixNano()
+	loc, ok := c.index[key]
+	if !ok || loc.expired(now) {
+		return nil, 0, nil
+	}
+	value := make([]byte, loc.length)
+	if _, err := c.file.ReadAt(value, loc.offset); err != nil {
+		return nil, 0, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	if loc.expires == 0 {
+		return value, 0, nil
+	}
+	return value, time.Duration(loc.expires - now), nil
+}
+
+func (c *DiskCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if len(key)+len(value) > maxRecordSize {
+		return fmt.Errorf("cache entry of %d bytes is too large", len(key)+len(value))
+	}
+	rec := &record{op: opSet, key: key, value: value}
+	if ttl > 0 {
+		rec.expires = time.Now().Add(ttl).UnixNano()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	offset, err := c.append(rec)
+	if err != nil {
+		return err
+	}
+	c.forget(key)
+	c.index[key] = location{
+		offset:  offset + headerSize + int64(len(key)),
+		length:  len(value),
+		expires: rec.expires,
+		size:    rec.size(),
+	}
+	return nil
+}
+
+func (c *DiskCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.index[key]; !ok {
+		return nil
+	}
+	return c.delete(key)
+}
+
+func (c *DiskCache) DeleteMatching(ctx context.Context, pattern string) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deleted := 0
+	for key := range c.index {
+		if matched, _ := path.Match(pattern, key); !matched {
+			continue
+		}
+		if err := c.delete(key); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (c *DiskCache) Clear(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to clear disk cache: %w", err)
+	}
+	c.index = make(map[string]location)
+	c.size = 0
+	c.garbage = 0
+	c.dirty = true
+	return nil
+}
+
+// Close syncs and closes the log and releases the directory. Calling it
+// again returns the result of the first call.
+func (c *DiskCache) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		<-c.done
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		defer c.lock.Close()
+		if err := c.file.Sync(); err != nil {
+			c.file.Close()
+			c.closeErr = err
+			return
+		}
+		c.closeErr = c.file.Close()
+	})
+	return c.closeErr
+}
+
+// GetStats reports the size of the log, including data awaiting compaction,
+// as Bytes
+func (c *DiskCache) GetStats() types.Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return types.Stats{
+		Hits:   atomic.LoadUint64(&c.stats.Hits),
+		Misses: atomic.LoadUint64(&c.stats.Misses),
+		Keys:   uint64(len(c.index)),
+		Bytes:  uint64(c.size),
+	}
+}
+
+// Compact rewrites the log with only the live entries and atomically
+// replaces the old log with it
+func (c *DiskCache) Compact() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Opened for reading and appending, so that once renamed it replaces
+	// the old log without being reopened
+	path := filepath.Join(c.dir, compactName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted log: %w", err)
+	}
+	defer os.Remove(path)
+
+	w := bufio.NewWriter(f)
+	index := make(map[string]location, len(c.index))
+	var size int64
+	now := time.Now().UnixNano()
+	for key, loc := range c.index {
+		if loc.expired(now) {
+			continue
+		}
+		value := make([]byte, loc.length)
+		if _, err := c.file.ReadAt(value, loc.offset); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to read cache entry: %w", err)
+		}
+		rec := &record{op: opSet, expires: loc.expires, key: key, value: value}
+		if _, err := w.Write(rec.encode()); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write compacted log: %w", err)
+		}
+		index[key] = location{
+			offset:  size + headerSize + int64(len(key)),
+			length:  loc.length,
+			expires: loc.expires,
+			size:    rec.size(),
+		}
+		size += rec.size()
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write compacted log: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync compacted log: %w", err)
+	}
+
+	if err := os.Rename(path, filepath.Join(c.dir, logName)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to replace log: %w", err)
+	}
+	syncDir(c.dir)
+	c.file.Close()
+	c.file = f
+	c.index = index
+	c.size = size
+	c.garbage = 0
+	c.dirty = false
+	return nil
+}
+
+func (c *DiskCache) open() error {
+	f, err := os.OpenFile(filepath.Join(c.dir, logName), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open disk cache: %w", err)
+	}
+	c.file = f
+	return nil
+}
+
+// recover rebuilds the index from the log. A record whose checksum does not
+// match is skipped, since its header still says where the next one starts.
+// It may have been a newer value or a deletion, so its key is dropped
+// rather than left at an older value. If the damage is in the key itself,
+// the original key cannot be known and keeps its older value.
+// A record that cannot be framed, or is cut short, ends the log: it and
+// everything after it are truncated.
+func (c *DiskCache) recover() error {
+	r := bufio.NewReader(io.NewSectionReader(c.file, 0, 1<<62))
+	now := time.Now().UnixNano()
+	var offset int64
+	for {
+		rec, err := readRecord(r)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, errChecksum) {
+			logger.Warn("Skipping damaged disk cache record",
+				zap.Int64("offset", offset),
+				zap.Int64("size", rec.size()))
+			c.forget(rec.key)
+			c.garbage += rec.size()
+			offset += rec.size()
+			continue
+		}
+		if err != nil {
+			if !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, errCorrupt) {
+				return fmt.Errorf("failed to read disk cache: %w", err)
+			}
+			logger.Warn("Discarding damaged tail of disk cache",
+				zap.Int64("offset", offset),
+				zap.Error(err))
+			if err := c.file.Truncate(offset); err != nil {
+				return fmt.Errorf("failed to truncate disk cache: %w", err)
+			}
+			break
+		}
+
+		c.forget(rec.key)
+		switch {
+		case rec.op == opDelete:
+			c.garbage += rec.size()
+		case rec.expires != 0 && now > rec.expires:
+			c.garbage += rec.size()
+		default:
+			c.index[rec.key] = location{
+				offset:  offset + headerSize + int64(len(rec.key)),
+				length:  len(rec.value),
+				expires: rec.expires,
+				size:    rec.size(),
+			}
+		}
+		offset += rec.size()
+	}
+	c.size = offset
+
+	logger.Info("Loaded disk cache",
+		zap.String("dir", c.dir),
+		zap.Int("entries", len(c.index)),
+		zap.Int64("bytes", c.size))
+	return nil
+}
+
+// append writes a record at the end of the log and returns its offset. A
+// partially written record is cut off again.
+func (c *DiskCache) append(rec *record) (int64, error) {
+	offset := c.size
+	if _, err := c.file.Write(rec.encode()); err != nil {
+		c.file.Truncate(offset)
+		return 0, fmt.Errorf("failed to write disk cache: %w", err)
+	}
+	c.size += rec.size()
+	c.dirty = true
+	return offset, nil
+}
+
+// delete appends a tombstone for a key, which keeps it deleted after a
+// restart
+func (c *DiskCache) delete(key string) error {
+	rec := &record{op: opDelete, key: key}
+	if _, err := c.append(rec); err != nil {
+		return err
+	}
+	c.forget(key)
+	c.garbage += rec.size()
+	return nil
+}
+
+// forget drops a key from the index, counting its record as garbage
+func (c *DiskCache) forget(key string) {
+	if loc, ok := c.index[key]; ok {
+		c.garbage += loc.size
+		delete(c.index, key)
+	}
+}
+
+func (c *DiskCache) maintain(syncInterval, cleanupInterval time.Duration) {
+	defer close(c.done)
+	syncTicker := time.NewTicker(syncInterval)
+	defer syncTicker.Stop()
+	cleanupTicker := time.NewTicker(cleanupInterval)
+	defer cleanupTicker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-syncTicker.C:
+			c.sync()
+		case <-cleanupTicker.C:
+			if c.removeExpired() {
+				if err := c.Compact(); err != nil {
+					logger.Error("Failed to compact disk cache",
+						zap.Error(err))
+				}
+			}
+		}
+	}
+}
+
+func (c *DiskCache) sync() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return
+	}
+	if err := c.file.Sync(); err != nil {
+		logger.Error("Failed to sync disk cache",
+			zap.Error(err))
+		return
+	}
+	c.dirty = false
+}
+
+// removeExpired drops expired entries from the index and reports whether
+// the log should be compacted
+func (c *DiskCache) removeExpired() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now().UnixNano()
+	for key, loc := range c.index {
+		if loc.expired(now) {
+			c.forget(key)
+		}
+	}
+	return c.garbage >= minCompactBytes && c.garbage*2 >= c.size
+}
+
+// syncDir makes a rename in dir durable. Not every platform supports it, so
+// failures are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
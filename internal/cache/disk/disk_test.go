@@ -0,0 +1,214 @@
+package disk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("production")
+	os.Exit(m.Run())
+}
+
+func openCache(t *testing.T, dir string) *DiskCache {
+	t.Helper()
+	c, err := NewDiskCache(Config{Dir: dir, SyncInterval: time.Hour, CleanupInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewDiskCache: %v", err)
+	}
+	return c
+}
+
+// writeEntries fills a new cache in dir and closes it
+func writeEntries(t *testing.T, dir string, keys ...string) {
+	t.Helper()
+	c := openCache(t, dir)
+	for _, key := range keys {
+		if err := c.Set(context.Background(), key, []byte("value of "+key), 0); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+// recordSize is the size of a record written by writeEntries
+func recordSize(key string) int64 {
+	return (&record{key: key, value: []byte("value of " + key)}).size()
+}
+
+func checkEntries(t *testing.T, c *DiskCache, present, absent []string) {
+	t.Helper()
+	for _, key := range present {
+		value, err := c.Get(context.Background(), key)
+		if err != nil || string(value) != "value of "+key {
+			t.Errorf("Get(%s) = %q, %v", key, value, err)
+		}
+	}
+	for _, key := range absent {
+		if value, _ := c.Get(context.Background(), key); value != nil {
+			t.Errorf("Get(%s) = %q, want a miss", key, value)
+		}
+	}
+}
+
+func TestRecoverTruncatesTornTail(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, "a", "b", "c")
+
+	// Cut the last record short, as a crash in the middle of a write would
+	logPath := filepath.Join(dir, logName)
+	intact := recordSize("a") + recordSize("b")
+	if err := os.Truncate(logPath, intact+headerSize+2); err != nil {
+		t.Fatal(err)
+	}
+
+	c := openCache(t, dir)
+	checkEntries(t, c, []string{"a", "b"}, []string{"c"})
+	if info, _ := os.Stat(logPath); info.Size() != intact {
+		t.Errorf("log is %d bytes after recovery, want %d", info.Size(), intact)
+	}
+
+	// New writes follow the intact records and survive a restart
+	if err := c.Set(context.Background(), "d", []byte("value of d"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	c.Close()
+	c = openCache(t, dir)
+	defer c.Close()
+	checkEntries(t, c, []string{"a", "b", "d"}, []string{"c"})
+}
+
+func TestRecoverSkipsBadChecksum(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, "a", "b", "c")
+
+	// Flip a byte in the value of the middle record
+	logPath := filepath.Join(dir, logName)
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[recordSize("a")+headerSize+1] ^= 0xff
+	if err := os.WriteFile(logPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := openCache(t, dir)
+	defer c.Close()
+	checkEntries(t, c, []string{"a", "c"}, []string{"b"})
+	if stats := c.GetStats(); stats.Bytes != uint64(len(data)) {
+		t.Errorf("log is %d bytes, want %d kept", stats.Bytes, len(data))
+	}
+	if c.garbage != recordSize("b") {
+		t.Errorf("garbage = %d, want the skipped record's %d", c.garbage, recordSize("b"))
+	}
+}
+
+func TestRecoverDropsKeyOfBadChecksum(t *testing.T) {
+	dir := t.TempDir()
+	c := openCache(t, dir)
+	ctx := context.Background()
+	c.Set(ctx, "a", []byte("old"), 0)
+	c.Set(ctx, "b", []byte("value of b"), 0)
+	c.Delete(ctx, "b")
+	oldSize := c.GetStats().Bytes
+	c.Set(ctx, "a", []byte("new"), 0)
+	c.Close()
+
+	// Damage the tombstone of b and the newer value of a: neither key may
+	// come back at its older value
+	logPath := filepath.Join(dir, logName)
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tombstone := int64(oldSize) - (&record{key: "b"}).size()
+	data[tombstone+5] ^= 0xff
+	data[oldSize+headerSize+1] ^= 0xff
+	if err := os.WriteFile(logPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c = openCache(t, dir)
+	defer c.Close()
+	for _, key := range []string{"a", "b"} {
+		if value, _ := c.Get(ctx, key); value != nil {
+			t.Errorf("Get(%s) = %q after its newer record was damaged, want a miss", key, value)
+		}
+	}
+}
+
+func TestWritesAfterCompactionSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, "a", "b", "c")
+
+	c := openCache(t, dir)
+	ctx := context.Background()
+	c.Delete(ctx, "b")
+	if err := c.Compact(); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	checkEntries(t, c, []string{"a", "c"}, []string{"b"})
+	if err := c.Set(ctx, "d", []byte("value of d"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	c.Close()
+
+	c = openCache(t, dir)
+	defer c.Close()
+	checkEntries(t, c, []string{"a", "c", "d"}, []string{"b"})
+	if _, err := os.Stat(filepath.Join(dir, compactName)); !os.IsNotExist(err) {
+		t.Errorf("compacted log left behind: %v", err)
+	}
+}
+
+func TestRecoverTruncatesUnframedRecord(t *testing.T) {
+	dir := t.TempDir()
+	writeEntries(t, dir, "a", "b", "c")
+
+	// An unknown operation leaves the record's length unknown
+	logPath := filepath.Join(dir, logName)
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[recordSize("a")+4] = 0
+	if err := os.WriteFile(logPath, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := openCache(t, dir)
+	defer c.Close()
+	checkEntries(t, c, []string{"a"}, []string{"b", "c"})
+	if info, _ := os.Stat(logPath); info.Size() != recordSize("a") {
+		t.Errorf("log is %d bytes after recovery, want %d", info.Size(), recordSize("a"))
+	}
+}
+
+func TestDirectoryIsLocked(t *testing.T) {
+	dir := t.TempDir()
+	c := openCache(t, dir)
+
+	if _, err := NewDiskCache(Config{Dir: dir}); !errors.Is(err, errLocked) {
+		t.Fatalf("second cache on the directory returned %v, want errLocked", err)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+
+	// Closing releases the directory
+	c = openCache(t, dir)
+	c.Close()
+}
@@ -0,0 +1,89 @@
+package disk
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// Record operations
+const (
+	opSet    byte = 1
+	opDelete byte = 2
+)
+
+// headerSize is the size of a record header: CRC, operation, expiry in unix
+// nanoseconds, key length and value length
+const headerSize = 4 + 1 + 8 + 4 + 4
+
+// maxRecordSize bounds the key and value of a record. Larger lengths can
+// only come from a corrupt header.
+const maxRecordSize = 64 << 20
+
+var (
+	errCorrupt  = errors.New("corrupt record")
+	errChecksum = errors.New("record checksum mismatch")
+)
+
+// record is one entry of the log. The CRC covers everything after itself, so
+// a record torn by a crash is detected on recovery.
+type record struct {
+	op      byte
+	expires int64
+	key     string
+	value   []byte
+}
+
+func (r *record) size() int64 {
+	return int64(headerSize + len(r.key) + len(r.value))
+}
+
+func (r *record) encode() []byte {
+	buf := make([]byte, r.size())
+	buf[4] = r.op
+	binary.LittleEndian.PutUint64(buf[5:], uint64(r.expires))
+	binary.LittleEndian.PutUint32(buf[13:], uint32(len(r.key)))
+	binary.LittleEndian.PutUint32(buf[17:], uint32(len(r.value)))
+	copy(buf[headerSize:], r.key)
+	copy(buf[headerSize+len(r.key):], r.value)
+	binary.LittleEndian.PutUint32(buf, crc32.ChecksumIEEE(buf[4:]))
+	return buf
+}
+
+// readRecord reads the next record. It returns io.EOF at a clean end of the
+// log, and io.ErrUnexpectedEOF or errCorrupt for a torn or damaged record.
+// For a complete record whose checksum does not match, it returns the
+// record, so that it can be stepped over, along with errChecksum.
+func readRecord(r io.Reader) (*record, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	keyLen := binary.LittleEndian.Uint32(header[13:])
+	valueLen := binary.LittleEndian.Uint32(header[17:])
+	if (header[4] != opSet && header[4] != opDelete) || keyLen > maxRecordSize || valueLen > maxRecordSize-keyLen {
+		return nil, errCorrupt
+	}
+
+	body := make([]byte, keyLen+valueLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	rec := &record{
+		op:      header[4],
+		expires: int64(binary.LittleEndian.Uint64(header[5:])),
+		key:     string(body[:keyLen]),
+		value:   body[keyLen:],
+	}
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(body)
+	if crc.Sum32() != binary.LittleEndian.Uint32(header) {
+		return rec, errChecksum
+	}
+	return rec, nil
+}
@@ -5,23 +5,85 @@ import (
 
 	"github.com/sivaratrisrinivas/web3/blockCheck/config"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/disk"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/memory"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/redis"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/tiered"
 )
 
 // NewCache creates a new cache instance based on configuration
 func NewCache(cfg *config.Config) (cache.Cache, error) {
 	switch cfg.Cache.Type {
 	case "redis":
-		return redis.NewRedisCache(redis.Config{
-			Host:     cfg.Redis.Host,
-			Port:     cfg.Redis.Port,
-			Password: cfg.Redis.Password,
-			DB:       cfg.Redis.DB,
-		})
+		return newRedisCache(cfg)
 	case "memory":
-		return memory.NewMemoryCache(cfg.Cache.TTL)
+		return newMemoryCache(cfg)
+	case "disk":
+		return disk.NewDiskCache(disk.Config{
+			Dir:             cfg.Cache.DiskDir,
+			SyncInterval:    cfg.Cache.DiskSyncInterval,
+			CleanupInterval: cfg.Cache.TTL / 2,
+		})
+	case "tiered":
+		l2, err := newRedisCache(cfg)
+		if err != nil {
+			return nil, err
+		}
+		l1, err := newMemoryCache(cfg)
+		if err != nil {
+			l2.Close()
+			return nil, err
+		}
+		c, err := tiered.NewTieredCache(l1, l2, tiered.Config{
+			L1TTL:   cfg.Cache.L1TTL,
+			Channel: cfg.Cache.InvalidationChannel,
+		})
+		if err != nil {
+			l1.Close()
+			l2.Close()
+			return nil, err
+		}
+		return c, nil
 	default:
 		return nil, fmt.Errorf("unsupported cache type: %s", cfg.Cache.Type)
 	}
 }
+
+func newRedisCache(cfg *config.Config) (*redis.RedisCache, error) {
+	return redis.NewRedisCache(RedisConfig(cfg))
+}
+
+// RedisConfig returns the connection settings of the Redis server, so that
+// other Redis-backed stores share the cache's mode, TLS and credentials
+func RedisConfig(cfg *config.Config) redis.Config {
+	return redis.Config{
+		Mode:             cfg.Redis.Mode,
+		Host:             cfg.Redis.Host,
+		Port:             cfg.Redis.Port,
+		Addrs:            cfg.Redis.Addrs,
+		MasterName:       cfg.Redis.MasterName,
+		Username:         cfg.Redis.Username,
+		Password:         cfg.Redis.Password,
+		SentinelUsername: cfg.Redis.SentinelUsername,
+		SentinelPassword: cfg.Redis.SentinelPassword,
+		DB:               cfg.Redis.DB,
+		Prefix:           cfg.Redis.KeyPrefix,
+		LockPrefix:       cfg.Redis.LockKeyPrefix,
+		TLS: redis.TLSConfig{
+			Enabled:            cfg.Redis.TLS,
+			CAFile:             cfg.Redis.TLSCAFile,
+			ServerName:         cfg.Redis.TLSServerName,
+			InsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		},
+	}
+}
+
+func newMemoryCache(cfg *config.Config) (*memory.MemoryCache, error) {
+	return memory.NewMemoryCache(memory.Config{
+		MaxEntries:      cfg.Cache.MaxEntries,
+		MaxBytes:        cfg.Cache.MaxBytes,
+		Policy:          cfg.Cache.EvictionPolicy,
+		Shards:          cfg.Cache.Shards,
+		CleanupInterval: cfg.Cache.TTL / 2,
+	})
+}
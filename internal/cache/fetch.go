@@ -0,0 +1,282 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/head"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+)
+
+const (
+	// loadTimeout bounds a load shared by coalesced callers or run in the
+	// background
+	loadTimeout = 30 * time.Second
+	// lockPollInterval is how often a replica waiting on another replica's
+	// load checks the cache
+	lockPollInterval = 50 * time.Millisecond
+	// notFoundMarkerTTL is how long a not-found result is kept for replicas
+	// waiting on the lock when negative caching is disabled
+	notFoundMarkerTTL = 4 * lockPollInterval
+)
+
+// NotFoundError is returned by loaders whose lookup succeeded but found
+// nothing, such as a name without a resolver. It is cached for the negative
+// TTL so that repeated lookups do not reach the node.
+type NotFoundError struct {
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Message
+}
+
+// envelope is the stored form of fetched values. It records when the value
+// was loaded so that it can still be served, stale, after it expires, and the
+// block it was computed at when the chain's head is tracked.
+type envelope struct {
+	Value    json.RawMessage `json:"value,omitempty"`
+	NotFound string          `json:"not_found,omitempty"`
+	StoredAt time.Time       `json:"stored_at"`
+	Expires  time.Time       `json:"expires"`
+	Block    *head.Block     `json:"block,omitempty"`
+}
+
+func (e *envelope) decode(value interface{}) error {
+	if e.NotFound != "" {
+		return &NotFoundError{Message: e.NotFound}
+	}
+	return json.Unmarshal(e.Value, value)
+}
+
+// fetch reads key into value, calling load on a miss and caching its result
+// for ttl. Expired values are served for up to StaleWhileRevalidate while
+// they are reloaded in the background, and for up to StaleIfError when load
+// fails. Not-found results are cached for the Negative TTL and never served
+// stale. Values computed at a block of chain that was reorged away are
+// dropped, and values superseded by new heads count as expired. The outcome
+// is recorded in the context's trace.
+func (ac *AddressCache) fetch(ctx context.Context, chain, key string, ttl time.Duration, value interface{}, load func(context.Context) (interface{}, error)) error {
+	now := time.Now()
+	cached, err := ac.readEnvelope(ctx, key)
+	if err != nil {
+		logger.Warn("Failed to read cache entry",
+			zap.String("key", key),
+			zap.Error(err))
+	}
+
+	if cached != nil {
+		reorged, superseded := ac.checkBlock(chain, cached)
+		switch {
+		case reorged:
+			logger.Debug("Dropping cache entry computed at a reorged block",
+				zap.String("key", key),
+				zap.Uint64("block", cached.Block.Number))
+			cached = nil
+		case superseded && now.Before(cached.Expires):
+			// Expired by the head; the stale windows start now
+			cached.Expires = now
+		}
+	}
+
+	if cached != nil {
+		age := now.Sub(cached.StoredAt)
+		if now.Before(cached.Expires) {
+			ac.count(key, true)
+			recordTrace(ctx, StatusCached, age)
+			return cached.decode(value)
+		}
+		if cached.NotFound == "" && now.Before(cached.Expires.Add(ac.ttls.StaleWhileRevalidate)) {
+			ac.refresh(chain, key, ttl, load)
+			ac.count(key, true)
+			recordTrace(ctx, StatusStale, age)
+			return cached.decode(value)
+		}
+	}
+
+	ac.count(key, false)
+	loaded, err := ac.load(ctx, chain, key, ttl, load)
+	if err == nil {
+		recordTrace(ctx, StatusFresh, 0)
+		return loaded.decode(value)
+	}
+	if cached != nil && cached.NotFound == "" && now.Before(cached.Expires.Add(ac.ttls.StaleIfError)) {
+		logger.Warn("Serving stale cache entry after lookup failure",
+			zap.String("key", key),
+			zap.Error(err))
+		recordTrace(ctx, StatusStale, now.Sub(cached.StoredAt))
+		return cached.decode(value)
+	}
+	return err
+}
+
+// load runs the loader for key once for all concurrent callers, who share
+// its result. Each caller stops waiting when its own context is done.
+func (ac *AddressCache) load(ctx context.Context, chain, key string, ttl time.Duration, load func(context.Context) (interface{}, error)) (*envelope, error) {
+	results := ac.inflight.DoChan(key, func() (interface{}, error) {
+		// Detached from the first caller, whose cancellation must not fail
+		// the others
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), loadTimeout)
+		defer cancel()
+		return ac.loadLocked(ctx, chain, key, ttl, load)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*envelope), nil
+	}
+}
+
+// loadLocked loads key under the distributed lock, if any. A replica that
+// does not get the lock waits for the holder's result and only loads the key
+// itself if none appears in time.
+func (ac *AddressCache) loadLocked(ctx context.Context, chain, key string, ttl time.Duration, load func(context.Context) (interface{}, error)) (*envelope, error) {
+	if ac.locker != nil {
+		unlock, acquired, err := ac.locker.TryLock(ctx, "lock:"+key, ac.lockTimeout)
+		switch {
+		case err != nil:
+			logger.Warn("Failed to take cache lock",
+				zap.String("key", key),
+				zap.Error(err))
+		case acquired:
+			defer unlock()
+		default:
+			if loaded := ac.awaitLoad(ctx, chain, key); loaded != nil {
+				return loaded, nil
+			}
+		}
+	}
+	return ac.store(ctx, chain, key, ttl, load)
+}
+
+// awaitLoad polls for an entry being loaded by another replica until it
+// appears or the lock times out
+func (ac *AddressCache) awaitLoad(ctx context.Context, chain, key string) *envelope {
+	deadline := time.Now().Add(ac.lockTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(lockPollInterval):
+		}
+		cached, err := ac.readEnvelope(ctx, key)
+		if err != nil || cached == nil || !time.Now().Before(cached.Expires) {
+			continue
+		}
+		if reorged, superseded := ac.checkBlock(chain, cached); !reorged && !superseded {
+			return cached
+		}
+	}
+	return nil
+}
+
+// lookup reads key into value if it is cached and fresh, without loading
+// it. Stale entries, and entries computed at a reorged or superseded block,
+// are misses: callers are expected to load them and put the result. A cached
+// not-found result is returned as a NotFoundError.
+func (ac *AddressCache) lookup(ctx context.Context, chain, key string, value interface{}) (bool, error) {
+	cached, err := ac.readEnvelope(ctx, key)
+	if err != nil {
+		logger.Warn("Failed to read cache entry",
+			zap.String("key", key),
+			zap.Error(err))
+	}
+	if cached != nil {
+		reorged, superseded := ac.checkBlock(chain, cached)
+		if !reorged && !superseded && time.Now().Before(cached.Expires) {
+			ac.count(key, true)
+			recordTrace(ctx, StatusCached, time.Since(cached.StoredAt))
+			return true, cached.decode(value)
+		}
+	}
+	ac.count(key, false)
+	return false, nil
+}
+
+// store calls the loader, pinned to the chain's head if it is tracked, and
+// stores its result tagged with that block
+func (ac *AddressCache) store(ctx context.Context, chain, key string, ttl time.Duration, load func(context.Context) (interface{}, error)) (*envelope, error) {
+	block := ac.headBlock(chain)
+	if block != nil {
+		ctx = head.WithBlock(ctx, *block)
+	}
+	result, err := load(ctx)
+	return ac.put(ctx, key, ttl, block, result, err)
+}
+
+// put stores a loaded result tagged with the block it was computed at.
+// Not-found results are returned as an envelope; other errors are not
+// cached.
+func (ac *AddressCache) put(ctx context.Context, key string, ttl time.Duration, block *head.Block, result interface{}, err error) (*envelope, error) {
+	now := time.Now()
+
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		// Without negative caching the result is still stored briefly, so
+		// that replicas waiting on the lock see it instead of polling until
+		// the lock times out
+		negative := ac.ttls.Negative
+		if negative == 0 && ac.locker != nil {
+			negative = notFoundMarkerTTL
+		}
+		loaded := &envelope{NotFound: notFound.Message, StoredAt: now, Expires: now.Add(negative), Block: block}
+		if negative > 0 {
+			ac.writeEnvelope(ctx, key, loaded, negative)
+		}
+		return loaded, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	loaded := &envelope{Value: data, StoredAt: now, Expires: now.Add(ttl), Block: block}
+	// Keep the entry for as long as it may be served stale
+	ac.writeEnvelope(ctx, key, loaded, ttl+max(ac.ttls.StaleWhileRevalidate, ac.ttls.StaleIfError))
+	return loaded, nil
+}
+
+// refresh reloads key in the background unless a reload is already running
+func (ac *AddressCache) refresh(chain, key string, ttl time.Duration, load func(context.Context) (interface{}, error)) {
+	if _, running := ac.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer ac.refreshing.Delete(key)
+		if _, err := ac.load(context.Background(), chain, key, ttl, load); err != nil {
+			logger.Warn("Failed to revalidate cache entry",
+				zap.String("key", key),
+				zap.Error(err))
+		}
+	}()
+}
+
+func (ac *AddressCache) readEnvelope(ctx context.Context, key string) (*envelope, error) {
+	var cached envelope
+	found, err := ac.getJSON(ctx, key, &cached)
+	if err != nil || !found || cached.StoredAt.IsZero() {
+		// Values written without an envelope count as misses
+		return nil, err
+	}
+	return &cached, nil
+}
+
+func (ac *AddressCache) writeEnvelope(ctx context.Context, key string, e *envelope, ttl time.Duration) {
+	if err := ac.setJSON(ctx, key, e, ttl); err != nil {
+		logger.Warn("Failed to write cache entry",
+			zap.String("key", key),
+			zap.Error(err))
+	}
+}
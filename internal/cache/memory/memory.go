@@ -2,6 +2,8 @@ package memory
 
 import (
 	"context"
+	"hash/maphash"
+	"path"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -9,105 +11,188 @@ import (
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/types"
 )
 
-type item struct {
-	value      []byte
-	expiration time.Time
+// Eviction policies
+const (
+	PolicyLRU     = "lru"
+	PolicyTinyLFU = "tinylfu"
+)
+
+const (
+	defaultShards          = 16
+	defaultCleanupInterval = time.Minute
+)
+
+// Config bounds the cache. Zero limits are unlimited.
+type Config struct {
+	MaxEntries int
+	MaxBytes   int64
+	// Policy is PolicyLRU or PolicyTinyLFU (the default)
+	Policy string
+	// Shards is rounded up to a power of two; defaults to 16
+	Shards int
+	// CleanupInterval is how often expired entries are swept; defaults to
+	// one minute
+	CleanupInterval time.Duration
 }
 
+// MemoryCache is an in-process cache split into independently locked
+// shards, each evicting entries to stay within its share of the limits
 type MemoryCache struct {
-	items map[string]item
-	mu    sync.RWMutex
-	stats types.Stats
+	shards []*shard
+	shift  uint
+	seed   maphash.Seed
+	stats  types.Stats
+	stop   chan struct{}
+	closed sync.Once
 }
 
-func NewMemoryCache(defaultTTL time.Duration) (*MemoryCache, error) {
+func NewMemoryCache(cfg Config) (*MemoryCache, error) {
+	if cfg.Policy == "" {
+		cfg.Policy = PolicyTinyLFU
+	}
+	if cfg.Shards <= 0 {
+		cfg.Shards = defaultShards
+	}
+	// Keep at least one entry per shard
+	for cfg.MaxEntries > 0 && cfg.Shards > 1 && cfg.Shards > cfg.MaxEntries {
+		cfg.Shards /= 2
+	}
+	shards, bits := 1, uint(0)
+	for shards < cfg.Shards {
+		shards <<= 1
+		bits++
+	}
+	if cfg.CleanupInterval <= 0 {
+		cfg.CleanupInterval = defaultCleanupInterval
+	}
+
 	c := &MemoryCache{
-		items: make(map[string]item),
-		stats: types.Stats{},
+		shards: make([]*shard, shards),
+		shift:  64 - bits,
+		seed:   maphash.MakeSeed(),
+		stats:  types.Stats{},
+		stop:   make(chan struct{}),
+	}
+	perShardEntries := ceilDiv(int64(cfg.MaxEntries), int64(shards))
+	perShardBytes := ceilDiv(cfg.MaxBytes, int64(shards))
+	for i := range c.shards {
+		c.shards[i] = newShard(cfg.Policy, int(perShardEntries), perShardBytes)
 	}
 
 	// Start cleanup goroutine
-	go c.cleanup(defaultTTL)
+	go c.cleanup(cfg.CleanupInterval)
 
 	return c, nil
 }
 
 func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	if item, exists := c.items[key]; exists {
-		if item.expiration.IsZero() || time.Now().Before(item.expiration) {
-			atomic.AddUint64(&c.stats.Hits, 1)
-			return item.value, nil
-		}
-		// Remove expired item
-		delete(c.items, key)
+	hash := maphash.String(c.seed, key)
+	if value, ok := c.shard(hash).get(key, hash, time.Now()); ok {
+		atomic.AddUint64(&c.stats.Hits, 1)
+		return value, nil
 	}
 
 	atomic.AddUint64(&c.stats.Misses, 1)
 	return nil, nil
 }
 
-func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// GetWithTTL returns a value with its remaining TTL without counting it as
+// an access
+func (c *MemoryCache) GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	now := time.Now()
+	value, expiration, ok := c.shard(maphash.String(c.seed, key)).peek(key, now)
+	if !ok {
+		return nil, 0, nil
+	}
+	if expiration.IsZero() {
+		return value, 0, nil
+	}
+	return value, expiration.Sub(now), nil
+}
 
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
 	var expiration time.Time
 	if ttl > 0 {
 		expiration = time.Now().Add(ttl)
 	}
 
-	c.items[key] = item{
-		value:      value,
-		expiration: expiration,
-	}
+	hash := maphash.String(c.seed, key)
+	c.shard(hash).set(key, hash, value, expiration)
 	return nil
 }
 
 func (c *MemoryCache) Delete(ctx context.Context, key string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	delete(c.items, key)
+	c.shard(maphash.String(c.seed, key)).delete(key)
 	return nil
 }
 
-func (c *MemoryCache) Clear(ctx context.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+func (c *MemoryCache) DeleteMatching(ctx context.Context, pattern string) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+	match := func(key string) bool {
+		matched, _ := path.Match(pattern, key)
+		return matched
+	}
+	deleted := 0
+	for _, s := range c.shards {
+		deleted += s.deleteMatching(match)
+	}
+	return deleted, nil
+}
 
-	c.items = make(map[string]item)
+func (c *MemoryCache) Clear(ctx context.Context) error {
+	for _, s := range c.shards {
+		s.clear()
+	}
 	return nil
 }
 
+// Close stops the cleanup goroutine; it is safe to call more than once
 func (c *MemoryCache) Close() error {
+	c.closed.Do(func() { close(c.stop) })
 	return nil
 }
 
 func (c *MemoryCache) GetStats() types.Stats {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	return types.Stats{
+	stats := types.Stats{
 		Hits:   atomic.LoadUint64(&c.stats.Hits),
 		Misses: atomic.LoadUint64(&c.stats.Misses),
-		Keys:   uint64(len(c.items)),
 	}
+	for _, s := range c.shards {
+		keys, bytes, evictions := s.stats()
+		stats.Keys += keys
+		stats.Bytes += bytes
+		stats.Evictions += evictions
+	}
+	return stats
+}
+
+func (c *MemoryCache) shard(hash uint64) *shard {
+	// The top bits pick the shard so that the sketch, indexed by the low
+	// bits, still sees well spread hashes
+	return c.shards[hash>>c.shift]
 }
 
-func (c *MemoryCache) cleanup(defaultTTL time.Duration) {
-	ticker := time.NewTicker(defaultTTL / 2)
+func (c *MemoryCache) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		c.mu.Lock()
-		now := time.Now()
-		for key, item := range c.items {
-			if !item.expiration.IsZero() && now.After(item.expiration) {
-				delete(c.items, key)
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			for _, s := range c.shards {
+				s.removeExpired(now)
 			}
 		}
-		c.mu.Unlock()
 	}
 }
+
+func ceilDiv(a, b int64) int64 {
+	if a <= 0 {
+		return 0
+	}
+	return (a + b - 1) / b
+}
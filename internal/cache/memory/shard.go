@@ -0,0 +1,330 @@
+package memory
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// entryOverhead approximates the per-entry bookkeeping memory (map slot,
+// list element and entry struct) counted towards the byte limit
+const entryOverhead = 96
+
+// Segments of a shard. LRU keeps every entry in the window.
+const (
+	segmentWindow = iota
+	segmentProbation
+	segmentProtected
+)
+
+type entry struct {
+	key        string
+	value      []byte
+	expiration time.Time
+	hash       uint64
+	size       int64
+	segment    int
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiration.IsZero() && now.After(e.expiration)
+}
+
+// segment is an LRU list with its size
+type segment struct {
+	list       *list.List
+	entries    int
+	bytes      int64
+	maxEntries int
+	maxBytes   int64
+}
+
+func newSegment(maxEntries int, maxBytes int64) *segment {
+	return &segment{
+		list:       list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+}
+
+// over reports whether the segment exceeds its limits; zero means unlimited
+func (s *segment) over() bool {
+	return (s.maxEntries > 0 && s.entries > s.maxEntries) || (s.maxBytes > 0 && s.bytes > s.maxBytes)
+}
+
+// shard is an independently locked part of the cache. With W-TinyLFU new
+// entries enter a small LRU window; entries leaving it are only admitted
+// to the main segmented LRU if they are accessed more often than the entry
+// they would evict. Frequently used entries are thereby protected from
+// one-off lookups, such as a flood of random names.
+type shard struct {
+	mu         sync.Mutex
+	items      map[string]*list.Element
+	segments   [3]*segment
+	maxEntries int
+	maxBytes   int64
+	sketch     *sketch // nil for LRU
+	evictions  uint64
+}
+
+func newShard(policy string, maxEntries int, maxBytes int64) *shard {
+	s := &shard{
+		items:      make(map[string]*list.Element),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+	}
+	if policy != PolicyTinyLFU {
+		s.segments[segmentWindow] = newSegment(0, 0)
+		s.segments[segmentProbation] = newSegment(0, 0)
+		s.segments[segmentProtected] = newSegment(0, 0)
+		return s
+	}
+
+	// 1% window, main split 20% probation / 80% protected
+	windowEntries, mainEntries := 0, 0
+	if maxEntries > 0 {
+		windowEntries = max(1, maxEntries/100)
+		mainEntries = max(1, maxEntries-windowEntries)
+	}
+	var windowBytes, mainBytes int64
+	if maxBytes > 0 {
+		windowBytes = max(1, maxBytes/100)
+		mainBytes = max(1, maxBytes-windowBytes)
+	}
+	s.segments[segmentWindow] = newSegment(windowEntries, windowBytes)
+	s.segments[segmentProbation] = newSegment(0, 0)
+	s.segments[segmentProtected] = newSegment(mainEntries*8/10, mainBytes*8/10)
+
+	width := maxEntries
+	if width == 0 {
+		width = 1024
+	}
+	s.sketch = newSketch(min(width, 1<<20))
+	return s
+}
+
+func (s *shard) get(key string, hash uint64, now time.Time) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sketch != nil {
+		s.sketch.increment(hash)
+	}
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := elem.Value.(*entry)
+	if e.expired(now) {
+		s.remove(elem)
+		return nil, false
+	}
+	s.touch(elem)
+	return e.value, true
+}
+
+// peek returns an entry without counting it as an access
+func (s *shard) peek(key string, now time.Time) ([]byte, time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok || elem.Value.(*entry).expired(now) {
+		return nil, time.Time{}, false
+	}
+	e := elem.Value.(*entry)
+	return e.value, e.expiration, true
+}
+
+func (s *shard) set(key string, hash uint64, value []byte, expiration time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := int64(len(key)+len(value)) + entryOverhead
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+	if s.maxBytes > 0 && size > s.maxBytes {
+		// Larger than the whole shard; caching it would empty the shard
+		return
+	}
+
+	if s.sketch != nil {
+		s.sketch.increment(hash)
+	}
+	e := &entry{
+		key:        key,
+		value:      value,
+		expiration: expiration,
+		hash:       hash,
+		size:       size,
+		segment:    segmentWindow,
+	}
+	s.items[key] = s.push(e, segmentWindow)
+	s.evict()
+}
+
+func (s *shard) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.remove(elem)
+	}
+}
+
+// deleteMatching removes the entries whose keys match and returns how many
+func (s *shard) deleteMatching(match func(string) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deleted := 0
+	for key, elem := range s.items {
+		if match(key) {
+			s.remove(elem)
+			deleted++
+		}
+	}
+	return deleted
+}
+
+func (s *shard) clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string]*list.Element)
+	for _, seg := range s.segments {
+		seg.list.Init()
+		seg.entries = 0
+		seg.bytes = 0
+	}
+}
+
+func (s *shard) removeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, elem := range s.items {
+		if elem.Value.(*entry).expired(now) {
+			s.remove(elem)
+		}
+	}
+}
+
+// stats returns the shard's entry count, bytes and evictions
+func (s *shard) stats() (uint64, uint64, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var bytes int64
+	for _, seg := range s.segments {
+		bytes += seg.bytes
+	}
+	return uint64(len(s.items)), uint64(bytes), s.evictions
+}
+
+// touch records a hit: window and protected entries move to the front of
+// their segment, probation entries are promoted to protected
+func (s *shard) touch(elem *list.Element) {
+	e := elem.Value.(*entry)
+	if e.segment != segmentProbation {
+		s.segments[e.segment].list.MoveToFront(elem)
+		return
+	}
+
+	s.unlink(elem)
+	s.items[e.key] = s.push(e, segmentProtected)
+	protected := s.segments[segmentProtected]
+	for protected.over() && protected.entries > 1 {
+		demoted := protected.list.Back()
+		s.unlink(demoted)
+		d := demoted.Value.(*entry)
+		s.items[d.key] = s.push(d, segmentProbation)
+	}
+}
+
+// evict restores the shard's limits. Entries overflowing the window compete
+// with the probation victim on access frequency; the loser is evicted. Ties
+// go to the victim, so once the main segment is full an entry that was
+// written but not read while in the window is dropped as it leaves it. Only
+// values that must survive unread, such as nonces or locks, need another
+// store.
+func (s *shard) evict() {
+	window := s.segments[segmentWindow]
+	for s.sketch != nil && window.over() && window.entries > 0 {
+		candidate := window.list.Back()
+		s.unlink(candidate)
+		c := candidate.Value.(*entry)
+		s.items[c.key] = s.push(c, segmentProbation)
+		candidate = s.items[c.key]
+
+		for s.over() {
+			victim := s.victim(candidate)
+			if victim == nil || s.sketch.estimate(c.hash) <= s.sketch.estimate(victim.Value.(*entry).hash) {
+				s.evictEntry(candidate)
+				break
+			}
+			s.evictEntry(victim)
+		}
+	}
+
+	// Entries can still exceed the byte limit, e.g. after a large value
+	// landed in the window
+	for s.over() {
+		var oldest *list.Element
+		for _, seg := range []int{segmentProbation, segmentProtected, segmentWindow} {
+			if oldest = s.segments[seg].list.Back(); oldest != nil {
+				break
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		s.evictEntry(oldest)
+	}
+}
+
+// victim returns the main segment entry that candidate competes with
+func (s *shard) victim(candidate *list.Element) *list.Element {
+	for _, seg := range []int{segmentProbation, segmentProtected} {
+		if victim := s.segments[seg].list.Back(); victim != nil && victim != candidate {
+			return victim
+		}
+	}
+	return nil
+}
+
+func (s *shard) over() bool {
+	entries, bytes := 0, int64(0)
+	for _, seg := range s.segments {
+		entries += seg.entries
+		bytes += seg.bytes
+	}
+	return (s.maxEntries > 0 && entries > s.maxEntries) || (s.maxBytes > 0 && bytes > s.maxBytes)
+}
+
+func (s *shard) evictEntry(elem *list.Element) {
+	s.remove(elem)
+	s.evictions++
+}
+
+func (s *shard) push(e *entry, seg int) *list.Element {
+	e.segment = seg
+	segment := s.segments[seg]
+	segment.entries++
+	segment.bytes += e.size
+	return segment.list.PushFront(e)
+}
+
+func (s *shard) unlink(elem *list.Element) {
+	e := elem.Value.(*entry)
+	segment := s.segments[e.segment]
+	segment.list.Remove(elem)
+	segment.entries--
+	segment.bytes -= e.size
+}
+
+func (s *shard) remove(elem *list.Element) {
+	s.unlink(elem)
+	delete(s.items, elem.Value.(*entry).key)
+}
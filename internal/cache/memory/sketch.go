@@ -0,0 +1,73 @@
+package memory
+
+// sketchDepth is the number of counter rows of the count-min sketch
+const sketchDepth = 4
+
+// sketch is a count-min sketch of 4-bit counters estimating how often keys
+// were accessed recently. Counters are halved every resetAfter increments so
+// that popularity ages out (the TinyLFU "reset" operation).
+type sketch struct {
+	rows       [sketchDepth][]uint8
+	mask       uint64
+	additions  int
+	resetAfter int
+}
+
+func newSketch(width int) *sketch {
+	size := 64
+	for size < width {
+		size <<= 1
+	}
+	s := &sketch{
+		mask:       uint64(size - 1),
+		resetAfter: 10 * size,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, size)
+	}
+	return s
+}
+
+// increment records an access of the key with the given hash
+func (s *sketch) increment(hash uint64) {
+	added := false
+	for i := range s.rows {
+		idx := s.index(hash, i)
+		if s.rows[i][idx] < 15 {
+			s.rows[i][idx]++
+			added = true
+		}
+	}
+	if added {
+		s.additions++
+		if s.additions >= s.resetAfter {
+			s.reset()
+		}
+	}
+}
+
+// estimate returns the approximate access frequency of a key
+func (s *sketch) estimate(hash uint64) uint8 {
+	min := uint8(15)
+	for i := range s.rows {
+		if v := s.rows[i][s.index(hash, i)]; v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+func (s *sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+// index derives the counter of row i from the two halves of the hash
+func (s *sketch) index(hash uint64, i int) uint64 {
+	h1, h2 := hash&0xffffffff, hash>>32|1
+	return (h1 + uint64(i)*h2) & s.mask
+}
@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Deployment modes
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+type Config struct {
+	// Mode is ModeStandalone (the default), ModeSentinel or ModeCluster
+	Mode string
+	// Host and Port address a standalone server, or a single sentinel or
+	// cluster node when Addrs is empty
+	Host string
+	Port int
+	// Addrs lists the sentinels or the cluster seed nodes as host:port
+	Addrs []string
+	// MasterName is the master monitored by the sentinels
+	MasterName string
+	// Username selects a Redis 6 ACL user; empty means the default user
+	Username string
+	Password string
+	// SentinelUsername and SentinelPassword authenticate with the sentinels
+	SentinelUsername string
+	SentinelPassword string
+	// DB is not supported by clusters
+	DB int
+	// Prefix namespaces every key, so that the cache can share a server
+	// with other applications. It is required.
+	Prefix string
+	// LockPrefix namespaces locks. It is required and must not fall under
+	// Prefix, which clearing the cache deletes.
+	LockPrefix string
+	TLS        TLSConfig
+}
+
+type TLSConfig struct {
+	Enabled bool
+	// CAFile verifies the server against a private CA instead of the
+	// system roots
+	CAFile     string
+	ServerName string
+	// InsecureSkipVerify disables certificate verification; for testing only
+	InsecureSkipVerify bool
+}
+
+// NewClient connects to a standalone server, sentinels or a cluster as
+// configured. cfg.Prefix is not applied; callers namespace their own keys.
+func NewClient(cfg Config) (redis.UniversalClient, error) {
+	tlsConfig, err := cfg.TLS.load()
+	if err != nil {
+		return nil, err
+	}
+	addrs := cfg.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)}
+	}
+
+	switch cfg.Mode {
+	case "", ModeStandalone:
+		return redis.NewClient(&redis.Options{
+			Addr:      fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+			Username:  cfg.Username,
+			Password:  cfg.Password,
+			DB:        cfg.DB,
+			TLSConfig: tlsConfig,
+		}), nil
+	case ModeSentinel:
+		if cfg.MasterName == "" {
+			return nil, fmt.Errorf("a master name is required in sentinel mode")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    addrs,
+			SentinelUsername: cfg.SentinelUsername,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         cfg.Username,
+			Password:         cfg.Password,
+			DB:               cfg.DB,
+			TLSConfig:        tlsConfig,
+		}), nil
+	case ModeCluster:
+		if cfg.DB != 0 {
+			return nil, fmt.Errorf("Redis clusters only support database 0")
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     addrs,
+			Username:  cfg.Username,
+			Password:  cfg.Password,
+			TLSConfig: tlsConfig,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unsupported Redis mode: %s", cfg.Mode)
+	}
+}
+
+// load returns the TLS client configuration, or nil if TLS is disabled
+func (c TLSConfig) load() (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Redis CA file: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in Redis CA file %s", c.CAFile)
+		}
+	}
+	return config, nil
+}
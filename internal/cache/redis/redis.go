@@ -2,7 +2,11 @@ package redis
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"path"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -10,41 +14,49 @@ import (
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/types"
 )
 
-type RedisCache struct {
-	client *redis.Client
-	stats  types.Stats
-}
+// scanBatch is the number of keys requested per SCAN call
+const scanBatch = 1000
 
-type Config struct {
-	Host     string
-	Port     int
-	Password string
-	DB       int
+// RedisCache stores every key under its prefix, and only ever reads, counts
+// or deletes keys under it. Locks are kept under their own prefix.
+type RedisCache struct {
+	client     redis.UniversalClient
+	prefix     string
+	lockPrefix string
+	stats      types.Stats
 }
 
 func NewRedisCache(cfg Config) (*RedisCache, error) {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
+	if cfg.Prefix == "" {
+		return nil, fmt.Errorf("a key prefix is required for the Redis cache")
+	}
+	if cfg.LockPrefix == "" || strings.HasPrefix(cfg.LockPrefix, cfg.Prefix) {
+		return nil, fmt.Errorf("the Redis cache's lock prefix must be set outside its key prefix")
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	// Test connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
 	return &RedisCache{
-		client: client,
-		stats:  types.Stats{},
+		client:     client,
+		prefix:     cfg.Prefix,
+		lockPrefix: cfg.LockPrefix,
+		stats:      types.Stats{},
 	}, nil
 }
 
 func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
-	val, err := c.client.Get(ctx, key).Bytes()
+	val, err := c.client.Get(ctx, c.prefix+key).Bytes()
 	if err == redis.Nil {
 		atomic.AddUint64(&c.stats.Misses, 1)
 		return nil, nil
@@ -56,26 +68,200 @@ func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	return val, nil
 }
 
+// GetWithTTL returns a value with its remaining TTL, which is zero for values
+// that do not expire
+func (c *RedisCache) GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	pipe := c.client.Pipeline()
+	get := pipe.Get(ctx, c.prefix+key)
+	pttl := pipe.PTTL(ctx, c.prefix+key)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, 0, err
+	}
+
+	val, err := get.Bytes()
+	if err == redis.Nil {
+		atomic.AddUint64(&c.stats.Misses, 1)
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	atomic.AddUint64(&c.stats.Hits, 1)
+	return val, max(pttl.Val(), 0), nil
+}
+
 func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
-	return c.client.Set(ctx, key, value, ttl).Err()
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
 }
 
 func (c *RedisCache) Delete(ctx context.Context, key string) error {
-	return c.client.Del(ctx, key).Err()
+	return c.client.Del(ctx, c.prefix+key).Err()
 }
 
+// Clear deletes the keys under the prefix, leaving other keys on the server
+// untouched
 func (c *RedisCache) Clear(ctx context.Context) error {
-	return c.client.FlushAll(ctx).Err()
+	_, err := c.deleteScanned(ctx, "*")
+	return err
+}
+
+// DeleteMatching removes the keys under the prefix that match a glob pattern
+func (c *RedisCache) DeleteMatching(ctx context.Context, pattern string) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, err
+	}
+	return c.deleteScanned(ctx, pattern)
+}
+
+func (c *RedisCache) deleteScanned(ctx context.Context, pattern string) (int, error) {
+	var deleted int64
+	err := c.scan(ctx, pattern, func(ctx context.Context, node redis.Cmdable, keys []string) error {
+		// One command per key: keys in different cluster slots cannot be
+		// deleted together
+		pipe := node.Pipeline()
+		for _, key := range keys {
+			pipe.Unlink(ctx, key)
+		}
+		_, err := pipe.Exec(ctx)
+		if err == nil {
+			atomic.AddInt64(&deleted, int64(len(keys)))
+		}
+		return err
+	})
+	return int(deleted), err
 }
 
 func (c *RedisCache) Close() error {
 	return c.client.Close()
 }
 
+// Publish sends a message to every subscriber of channel
+func (c *RedisCache) Publish(ctx context.Context, channel string, message []byte) error {
+	return c.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe returns the messages published on channel until ctx is done.
+// Messages published while the connection is being re-established are lost.
+func (c *RedisCache) Subscribe(ctx context.Context, channel string) (<-chan []byte, error) {
+	sub := c.client.Subscribe(ctx, channel)
+	// Wait for the subscription to be confirmed
+	if _, err := sub.Receive(ctx); err != nil {
+		sub.Close()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", channel, err)
+	}
+
+	messages := make(chan []byte)
+	go func() {
+		defer close(messages)
+		defer sub.Close()
+		received := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-received:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- []byte(msg.Payload):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return messages, nil
+}
+
+// unlockScript deletes a lock only if it still holds the caller's token, so
+// that a lock which expired and was taken by another replica is kept
+var unlockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+// TryLock takes a lock shared by every replica using the same Redis
+func (c *RedisCache) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	var token [16]byte
+	if _, err := rand.Read(token[:]); err != nil {
+		return nil, false, err
+	}
+	value := hex.EncodeToString(token[:])
+
+	key = c.lockPrefix + key
+	acquired, err := c.client.SetNX(ctx, key, value, ttl).Result()
+	if err != nil || !acquired {
+		return nil, false, err
+	}
+	unlock := func() {
+		// The caller's context may already be done
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		unlockScript.Run(ctx, c.client, []string{key}, value)
+	}
+	return unlock, true, nil
+}
+
+// GetStats counts only the keys under the prefix. Counting scans them, so it
+// takes time proportional to the size of the database.
 func (c *RedisCache) GetStats() types.Stats {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var keys uint64
+	c.scan(ctx, "*", func(ctx context.Context, node redis.Cmdable, batch []string) error {
+		atomic.AddUint64(&keys, uint64(len(batch)))
+		return nil
+	})
 	return types.Stats{
 		Hits:   atomic.LoadUint64(&c.stats.Hits),
 		Misses: atomic.LoadUint64(&c.stats.Misses),
-		Keys:   uint64(c.client.DBSize(context.Background()).Val()),
+		Keys:   keys,
+	}
+}
+
+// scan calls fn with each batch of keys under the prefix matching pattern,
+// together with the node holding them. Cluster masters are scanned
+// concurrently.
+func (c *RedisCache) scan(ctx context.Context, pattern string, fn func(ctx context.Context, node redis.Cmdable, keys []string) error) error {
+	match := escapePattern(c.prefix) + pattern
+	scanNode := func(ctx context.Context, node redis.Cmdable) error {
+		var cursor uint64
+		for {
+			keys, next, err := node.Scan(ctx, cursor, match, scanBatch).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err := fn(ctx, node, keys); err != nil {
+					return err
+				}
+			}
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	}
+
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+			return scanNode(ctx, node)
+		})
+	}
+	return scanNode(ctx, c.client)
+}
+
+// escapePattern escapes the glob characters of a SCAN MATCH pattern
+func escapePattern(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
 	}
+	return b.String()
 }
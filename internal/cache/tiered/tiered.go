@@ -0,0 +1,214 @@
+package tiered
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/memory"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/redis"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/types"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+)
+
+const (
+	defaultL1TTL   = time.Minute
+	defaultChannel = "blockcheck:cache:invalidate"
+)
+
+type Config struct {
+	// L1TTL caps how long entries stay in process. It bounds how stale an
+	// entry can get should an invalidation be lost.
+	L1TTL time.Duration
+	// Channel is the Redis pub/sub channel invalidations are sent on
+	Channel string
+}
+
+// TieredCache is an in-process L1 cache in front of a Redis L2 cache shared by
+// every replica. Reads are served from L1 when possible; writes go to both
+// tiers and drop the key from the other replicas' L1 through Redis pub/sub.
+type TieredCache struct {
+	l1      *memory.MemoryCache
+	l2      *redis.RedisCache
+	l1TTL   time.Duration
+	channel string
+	// origin identifies this replica's invalidations, which it skips
+	origin string
+	stop   context.CancelFunc
+}
+
+// invalidation is broadcast when a key changes, when keys matching Pattern
+// are deleted, or for all keys with All set
+type invalidation struct {
+	Origin  string `json:"origin"`
+	Key     string `json:"key,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	All     bool   `json:"all,omitempty"`
+}
+
+// NewTieredCache subscribes to invalidations from other replicas. Closing the
+// cache closes both tiers.
+func NewTieredCache(l1 *memory.MemoryCache, l2 *redis.RedisCache, cfg Config) (*TieredCache, error) {
+	if cfg.L1TTL <= 0 {
+		cfg.L1TTL = defaultL1TTL
+	}
+	if cfg.Channel == "" {
+		cfg.Channel = defaultChannel
+	}
+	var origin [8]byte
+	if _, err := rand.Read(origin[:]); err != nil {
+		return nil, err
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	messages, err := l2.Subscribe(ctx, cfg.Channel)
+	if err != nil {
+		stop()
+		return nil, err
+	}
+
+	c := &TieredCache{
+		l1:      l1,
+		l2:      l2,
+		l1TTL:   cfg.L1TTL,
+		channel: cfg.Channel,
+		origin:  hex.EncodeToString(origin[:]),
+		stop:    stop,
+	}
+	go c.listen(messages)
+	return c, nil
+}
+
+func (c *TieredCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if value, err := c.l1.Get(ctx, key); err == nil && value != nil {
+		return value, nil
+	}
+
+	value, ttl, err := c.l2.GetWithTTL(ctx, key)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	c.l1.Set(ctx, key, value, c.l1Expiry(ttl))
+	return value, nil
+}
+
+func (c *TieredCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := c.l2.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	c.l1.Set(ctx, key, value, c.l1Expiry(ttl))
+	c.publish(ctx, invalidation{Key: key})
+	return nil
+}
+
+func (c *TieredCache) Delete(ctx context.Context, key string) error {
+	if err := c.l2.Delete(ctx, key); err != nil {
+		return err
+	}
+	c.l1.Delete(ctx, key)
+	c.publish(ctx, invalidation{Key: key})
+	return nil
+}
+
+// GetWithTTL returns the shared L2 entry with its remaining TTL
+func (c *TieredCache) GetWithTTL(ctx context.Context, key string) ([]byte, time.Duration, error) {
+	return c.l2.GetWithTTL(ctx, key)
+}
+
+// DeleteMatching removes matching keys from L2 and from every replica's L1,
+// returning the number removed from L2
+func (c *TieredCache) DeleteMatching(ctx context.Context, pattern string) (int, error) {
+	deleted, err := c.l2.DeleteMatching(ctx, pattern)
+	if err != nil {
+		return deleted, err
+	}
+	c.l1.DeleteMatching(ctx, pattern)
+	c.publish(ctx, invalidation{Pattern: pattern})
+	return deleted, nil
+}
+
+func (c *TieredCache) Clear(ctx context.Context) error {
+	if err := c.l2.Clear(ctx); err != nil {
+		return err
+	}
+	c.l1.Clear(ctx)
+	c.publish(ctx, invalidation{All: true})
+	return nil
+}
+
+func (c *TieredCache) Close() error {
+	c.stop()
+	c.l1.Close()
+	return c.l2.Close()
+}
+
+// GetStats counts hits in either tier as hits and L2 misses as misses. Keys
+// are those in L2; Tiers holds each tier's own statistics.
+func (c *TieredCache) GetStats() types.Stats {
+	l1, l2 := c.l1.GetStats(), c.l2.GetStats()
+	return types.Stats{
+		Hits:      l1.Hits + l2.Hits,
+		Misses:    l2.Misses,
+		Keys:      l2.Keys,
+		Evictions: l1.Evictions,
+		Tiers: map[string]types.Stats{
+			"l1": l1,
+			"l2": l2,
+		},
+	}
+}
+
+// TryLock takes the lock in L2, so that it holds across replicas
+func (c *TieredCache) TryLock(ctx context.Context, key string, ttl time.Duration) (func(), bool, error) {
+	return c.l2.TryLock(ctx, key, ttl)
+}
+
+// l1Expiry keeps L1 entries no longer than they have left in L2 and at most
+// l1TTL
+func (c *TieredCache) l1Expiry(ttl time.Duration) time.Duration {
+	if ttl <= 0 || ttl > c.l1TTL {
+		return c.l1TTL
+	}
+	return ttl
+}
+
+func (c *TieredCache) publish(ctx context.Context, inv invalidation) {
+	inv.Origin = c.origin
+	message, err := json.Marshal(inv)
+	if err == nil {
+		err = c.l2.Publish(ctx, c.channel, message)
+	}
+	if err != nil {
+		// Other replicas keep their copy until it leaves L1
+		logger.Warn("Failed to publish cache invalidation",
+			zap.String("key", inv.Key),
+			zap.Error(err))
+	}
+}
+
+func (c *TieredCache) listen(messages <-chan []byte) {
+	ctx := context.Background()
+	for message := range messages {
+		var inv invalidation
+		if err := json.Unmarshal(message, &inv); err != nil {
+			logger.Warn("Ignoring malformed cache invalidation",
+				zap.Error(err))
+			continue
+		}
+		if inv.Origin == c.origin {
+			continue
+		}
+		switch {
+		case inv.All:
+			c.l1.Clear(ctx)
+		case inv.Pattern != "":
+			c.l1.DeleteMatching(ctx, inv.Pattern)
+		default:
+			c.l1.Delete(ctx, inv.Key)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Status says where the data behind a response came from
+type Status string
+
+const (
+	// StatusFresh means the data was just loaded from the node
+	StatusFresh Status = "fresh"
+	// StatusCached means the data was served from the cache within its TTL
+	StatusCached Status = "cached"
+	// StatusStale means the data was served from the cache after its TTL,
+	// while it is revalidated or because the node failed
+	StatusStale Status = "stale"
+)
+
+// rank orders statuses from most to least fresh
+func (s Status) rank() int {
+	switch s {
+	case StatusCached:
+		return 1
+	case StatusStale:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// Trace collects the cache status of every lookup made for one request. It
+// reports the least fresh status and the oldest data seen.
+type Trace struct {
+	mu     sync.Mutex
+	status Status
+	age    time.Duration
+}
+
+type traceKey struct{}
+
+// WithTrace returns a context whose cached lookups are recorded in the
+// returned trace
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	trace := &Trace{}
+	return context.WithValue(ctx, traceKey{}, trace), trace
+}
+
+// Result returns the least fresh status and the age of the oldest data, or
+// an empty status if no cached lookup was made
+func (t *Trace) Result() (Status, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status, t.age
+}
+
+func (t *Trace) record(status Status, age time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status == "" || status.rank() > t.status.rank() {
+		t.status = status
+	}
+	if age > t.age {
+		t.age = age
+	}
+}
+
+func recordTrace(ctx context.Context, status Status, age time.Duration) {
+	if trace, ok := ctx.Value(traceKey{}).(*Trace); ok {
+		trace.record(status, age)
+	}
+}
+
+// TraceMiddleware reports how the data behind each response was obtained in
+// the X-Cache header (fresh, cached or stale) and its age in seconds in the
+// Age header. Responses without cached lookups carry neither header.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, trace := WithTrace(r.Context())
+		next.ServeHTTP(&traceWriter{ResponseWriter: w, trace: trace}, r.WithContext(ctx))
+	})
+}
+
+// traceWriter sets the trace headers just before the response is written
+type traceWriter struct {
+	http.ResponseWriter
+	trace       *Trace
+	wroteHeader bool
+}
+
+func (w *traceWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status, age := w.trace.Result(); status != "" {
+			w.Header().Set("X-Cache", string(status))
+			w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *traceWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -5,4 +5,10 @@ type Stats struct {
 	Hits   uint64
 	Misses uint64
 	Keys   uint64
+	// Evictions counts entries removed to stay within the size limits
+	Evictions uint64
+	// Bytes is the approximate memory held by entries, where known
+	Bytes uint64
+	// Tiers breaks the statistics down by tier for layered caches
+	Tiers map[string]Stats
 }
@@ -0,0 +1,114 @@
+package calldata
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Argument is a decoded ABI value. Tuples decode to a list of Arguments and
+// arrays to a list of values.
+type Argument struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// AddressRef is an address found in the decoded arguments, with the path to
+// it such as "arg0", "order.maker" or "path[2]"
+type AddressRef struct {
+	Path    string
+	Address common.Address
+}
+
+// Decoded is calldata decoded against a method
+type Decoded struct {
+	Method    abi.Method
+	Arguments []Argument
+	Addresses []AddressRef
+	// Canonical is false when re-encoding the decoded values does not
+	// reproduce the input, e.g. dirty address padding or trailing bytes
+	Canonical bool
+}
+
+// Decode decodes calldata (selector included) against method
+func Decode(method abi.Method, data []byte) (*Decoded, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("calldata is shorter than a selector")
+	}
+	if !bytes.Equal(data[:4], method.ID) {
+		return nil, fmt.Errorf("selector %s does not match %s", hexutil.Encode(data[:4]), method.Sig)
+	}
+
+	values, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode arguments for %s: %w", method.Sig, err)
+	}
+
+	decoded := &Decoded{
+		Method:    method,
+		Arguments: make([]Argument, len(method.Inputs)),
+	}
+	for i, input := range method.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		decoded.Arguments[i] = Argument{
+			Name:  name,
+			Type:  input.Type.String(),
+			Value: format(input.Type, reflect.ValueOf(values[i]), name, &decoded.Addresses),
+		}
+	}
+
+	if packed, err := method.Inputs.Pack(values...); err == nil {
+		decoded.Canonical = bytes.Equal(packed, data[4:])
+	}
+	return decoded, nil
+}
+
+// format converts a decoded value into a JSON-friendly form, collecting
+// every address it contains
+func format(t abi.Type, v reflect.Value, path string, addresses *[]AddressRef) interface{} {
+	switch t.T {
+	case abi.AddressTy:
+		addr := v.Interface().(common.Address)
+		*addresses = append(*addresses, AddressRef{Path: path, Address: addr})
+		return addr.Hex()
+	case abi.IntTy, abi.UintTy:
+		return fmt.Sprint(v.Interface())
+	case abi.BoolTy:
+		return v.Bool()
+	case abi.StringTy:
+		return v.String()
+	case abi.BytesTy:
+		return hexutil.Encode(v.Bytes())
+	case abi.FixedBytesTy, abi.FunctionTy:
+		b := make([]byte, v.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hexutil.Encode(b)
+	case abi.SliceTy, abi.ArrayTy:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = format(*t.Elem, v.Index(i), fmt.Sprintf("%s[%d]", path, i), addresses)
+		}
+		return items
+	case abi.TupleTy:
+		fields := make([]Argument, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			name := t.TupleRawNames[i]
+			fields[i] = Argument{
+				Name:  name,
+				Type:  elem.String(),
+				Value: format(*elem, v.Field(i), path+"."+name, addresses),
+			}
+		}
+		return fields
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -3,8 +3,8 @@ package ens
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"strings"
-	"sync"
 	"time"
 
 	ethereum "github.com/ethereum/go-ethereum"
@@ -13,28 +13,34 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/head"
 )
 
 var log = logrus.New()
 
 // ENS Registry ABI
-const ensRegistryABI = `[{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"resolver","outputs":[{"name":"","type":"address"}],"payable":false,"type":"function"}]`
+const ensRegistryABI = `[{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"resolver","outputs":[{"name":"","type":"address"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"owner","outputs":[{"name":"","type":"address"}],"payable":false,"type":"function"}]`
+
+// ENS token ABI, shared by the .eth registrar and the NameWrapper
+const ensTokenABI = `[{"constant":true,"inputs":[{"name":"id","type":"uint256"}],"name":"ownerOf","outputs":[{"name":"","type":"address"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"id","type":"uint256"}],"name":"nameExpires","outputs":[{"name":"","type":"uint256"}],"payable":false,"type":"function"}]`
 
 // ENS Resolver ABI
-const ensResolverABI = `[{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"addr","outputs":[{"name":"","type":"address"}],"payable":false,"type":"function"}]`
+const ensResolverABI = `[{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"addr","outputs":[{"name":"","type":"address"}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"node","type":"bytes32"}],"name":"name","outputs":[{"name":"","type":"string"}],"payable":false,"type":"function"}]`
 
-type Resolver struct {
-	client        *ethclient.Client
-	cache         map[string]cacheEntry
-	cacheMutex    sync.RWMutex
-	cacheDuration time.Duration
-	registryABI   abi.ABI
-	resolverABI   abi.ABI
-}
+// ENS contract addresses on mainnet
+var (
+	registryAddress      = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+	baseRegistrarAddress = common.HexToAddress("0x57f1887a8BF19b14fC0dF6Fd9B2acc9Af147eA85")
+	nameWrapperAddress   = common.HexToAddress("0xD4416b13d2b3a9aBae7AcD5D6C2BbDBE25686401")
+)
 
-type cacheEntry struct {
-	address   common.Address
-	timestamp time.Time
+type Resolver struct {
+	client      *ethclient.Client
+	cache       *cache.AddressCache
+	registryABI abi.ABI
+	resolverABI abi.ABI
+	tokenABI    abi.ABI
 }
 
 type ResolveResult struct {
@@ -43,7 +49,22 @@ type ResolveResult struct {
 	Error   string         `json:"error,omitempty"`
 }
 
-func NewResolver(providerURL string, cacheDuration time.Duration) (*Resolver, error) {
+// Record is the current on-chain state of an ENS name
+type Record struct {
+	Name     string
+	Resolver common.Address
+	Address  common.Address
+	// Owner is the registrant of .eth second-level names and the registry
+	// owner of other names. Wrapped names report the NameWrapper token owner.
+	Owner common.Address
+	// Expiry is zero for names that do not expire, i.e. anything other than
+	// .eth second-level names
+	Expiry time.Time
+}
+
+// NewResolver connects to the node at providerURL. Forward and reverse
+// results are cached in addressCache unless it is nil.
+func NewResolver(providerURL string, addressCache *cache.AddressCache) (*Resolver, error) {
 	log.Infof("Connecting to Ethereum node at %s", providerURL)
 	client, err := ethclient.Dial(providerURL)
 	if err != nil {
@@ -62,12 +83,17 @@ func NewResolver(providerURL string, cacheDuration time.Duration) (*Resolver, er
 		return nil, fmt.Errorf("failed to parse resolver ABI: %w", err)
 	}
 
+	tokenABI, err := abi.JSON(strings.NewReader(ensTokenABI))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token ABI: %w", err)
+	}
+
 	return &Resolver{
-		client:        client,
-		cache:         make(map[string]cacheEntry),
-		cacheDuration: cacheDuration,
-		registryABI:   registryABI,
-		resolverABI:   resolverABI,
+		client:      client,
+		cache:       addressCache,
+		registryABI: registryABI,
+		resolverABI: resolverABI,
+		tokenABI:    tokenABI,
 	}, nil
 }
 
@@ -80,17 +106,7 @@ func (r *Resolver) Resolve(ctx context.Context, name string) (*ResolveResult, er
 
 	log.Debugf("Resolving ENS name: %s", name)
 
-	// Check cache first
-	if addr, ok := r.checkCache(name); ok {
-		log.Debugf("Cache hit for %s: %s", name, addr.Hex())
-		return &ResolveResult{
-			Name:    name,
-			Address: addr,
-		}, nil
-	}
-
-	// Resolve using ENS
-	address, err := r.resolveENS(ctx, name)
+	address, err := r.resolveCached(ctx, name)
 	if err != nil {
 		log.Errorf("Failed to resolve ENS name: %v", err)
 		return &ResolveResult{
@@ -99,9 +115,6 @@ func (r *Resolver) Resolve(ctx context.Context, name string) (*ResolveResult, er
 		}, nil
 	}
 
-	// Update cache
-	r.updateCache(name, address)
-
 	log.Infof("Successfully resolved %s to %s", name, address.Hex())
 	return &ResolveResult{
 		Name:    name,
@@ -109,26 +122,23 @@ func (r *Resolver) Resolve(ctx context.Context, name string) (*ResolveResult, er
 	}, nil
 }
 
-func (r *Resolver) checkCache(name string) (common.Address, bool) {
-	r.cacheMutex.RLock()
-	defer r.cacheMutex.RUnlock()
-
-	if entry, exists := r.cache[name]; exists {
-		if time.Since(entry.timestamp) < r.cacheDuration {
-			return entry.address, true
-		}
+// resolveCached resolves a name through the cache, which also remembers
+// names without an address for a short while
+func (r *Resolver) resolveCached(ctx context.Context, name string) (common.Address, error) {
+	if r.cache == nil {
+		return r.resolveENS(ctx, name)
 	}
-	return common.Address{}, false
-}
-
-func (r *Resolver) updateCache(name string, address common.Address) {
-	r.cacheMutex.Lock()
-	defer r.cacheMutex.Unlock()
-
-	r.cache[name] = cacheEntry{
-		address:   address,
-		timestamp: time.Now(),
+	address, err := r.cache.FetchENSAddress(ctx, name, func(ctx context.Context) (string, error) {
+		address, err := r.resolveENS(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		return address.Hex(), nil
+	})
+	if err != nil {
+		return common.Address{}, err
 	}
+	return common.HexToAddress(address), nil
 }
 
 func (r *Resolver) resolveENS(ctx context.Context, name string) (common.Address, error) {
@@ -138,8 +148,6 @@ func (r *Resolver) resolveENS(ctx context.Context, name string) (common.Address,
 	node := NameHash(name)
 	log.Debugf("Calculated namehash for %s: %x", name, node)
 
-	// ENS Registry address on mainnet
-	registryAddress := common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
 	log.Debugf("Using ENS Registry at %s", registryAddress.Hex())
 
 	// Call resolver() function
@@ -153,7 +161,7 @@ func (r *Resolver) resolveENS(ctx context.Context, name string) (common.Address,
 		Data: data,
 	}
 
-	result, err := r.client.CallContract(ctx, msg, nil)
+	result, err := r.call(ctx, msg)
 	if err != nil {
 		if strings.Contains(err.Error(), "Unauthorized") {
 			return common.Address{}, fmt.Errorf("Infura authentication failed: %w", err)
@@ -162,7 +170,7 @@ func (r *Resolver) resolveENS(ctx context.Context, name string) (common.Address,
 	}
 
 	if len(result) == 0 {
-		return common.Address{}, fmt.Errorf("no resolver found for %s", name)
+		return common.Address{}, &cache.NotFoundError{Message: fmt.Sprintf("no resolver found for %s", name)}
 	}
 
 	var resolverAddr common.Address
@@ -171,7 +179,7 @@ func (r *Resolver) resolveENS(ctx context.Context, name string) (common.Address,
 	}
 
 	if resolverAddr == (common.Address{}) {
-		return common.Address{}, fmt.Errorf("no resolver found for %s", name)
+		return common.Address{}, &cache.NotFoundError{Message: fmt.Sprintf("no resolver found for %s", name)}
 	}
 	log.Debugf("Found resolver at %s", resolverAddr.Hex())
 
@@ -186,13 +194,13 @@ func (r *Resolver) resolveENS(ctx context.Context, name string) (common.Address,
 		Data: data,
 	}
 
-	result, err = r.client.CallContract(ctx, msg, nil)
+	result, err = r.call(ctx, msg)
 	if err != nil {
 		return common.Address{}, fmt.Errorf("failed to call addr: %w", err)
 	}
 
 	if len(result) == 0 {
-		return common.Address{}, fmt.Errorf("address not found for %s", name)
+		return common.Address{}, &cache.NotFoundError{Message: fmt.Sprintf("address not found for %s", name)}
 	}
 
 	var address common.Address
@@ -201,12 +209,189 @@ func (r *Resolver) resolveENS(ctx context.Context, name string) (common.Address,
 	}
 
 	if address == (common.Address{}) {
-		return common.Address{}, fmt.Errorf("address not found for %s", name)
+		return common.Address{}, &cache.NotFoundError{Message: fmt.Sprintf("address not found for %s", name)}
 	}
 
 	return address, nil
 }
 
+// LookupAddress returns the primary ENS name of an address via its
+// <addr>.addr.reverse record. The name is only returned if it resolves back
+// to the same address; an empty name means no verified primary name is set.
+func (r *Resolver) LookupAddress(ctx context.Context, address common.Address) (string, error) {
+	if r.cache == nil {
+		return r.lookupAddress(ctx, address)
+	}
+	return r.cache.FetchENSName(ctx, address.Hex(), func(ctx context.Context) (string, error) {
+		return r.lookupAddress(ctx, address)
+	})
+}
+
+func (r *Resolver) lookupAddress(ctx context.Context, address common.Address) (string, error) {
+	reverseName := strings.ToLower(strings.TrimPrefix(address.Hex(), "0x")) + ".addr.reverse"
+	node := NameHash(reverseName)
+	log.Debugf("Looking up primary name for %s", address.Hex())
+
+	resolverAddr, err := r.lookupResolver(ctx, node)
+	if err != nil {
+		return "", err
+	}
+	if resolverAddr == (common.Address{}) {
+		return "", nil
+	}
+
+	data, err := r.resolverABI.Pack("name", node)
+	if err != nil {
+		return "", fmt.Errorf("failed to pack name call: %w", err)
+	}
+	result, err := r.call(ctx, ethereum.CallMsg{To: &resolverAddr, Data: data})
+	if err != nil {
+		return "", fmt.Errorf("failed to call name: %w", err)
+	}
+	if len(result) == 0 {
+		return "", nil
+	}
+
+	var name string
+	if err := r.resolverABI.UnpackIntoInterface(&name, "name", result); err != nil {
+		return "", fmt.Errorf("failed to unpack name: %w", err)
+	}
+	if name == "" {
+		return "", nil
+	}
+
+	// Anyone can claim any name in their reverse record; only trust it if
+	// the forward record agrees
+	forward, err := r.resolveENS(ctx, name)
+	if err != nil || forward != address {
+		log.Debugf("Primary name %s for %s does not resolve back", name, address.Hex())
+		return "", nil
+	}
+	return name, nil
+}
+
+// Lookup reads the current resolver, addr record, owner and expiry of a
+// name, bypassing the cache. Unset fields are left zero.
+func (r *Resolver) Lookup(ctx context.Context, name string) (*Record, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasSuffix(name, ".eth") {
+		name = name + ".eth"
+	}
+	node := NameHash(name)
+	record := &Record{Name: name}
+
+	resolverAddr, err := r.lookupResolver(ctx, node)
+	if err != nil {
+		return nil, err
+	}
+	record.Resolver = resolverAddr
+
+	if resolverAddr != (common.Address{}) {
+		data, err := r.resolverABI.Pack("addr", node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack addr call: %w", err)
+		}
+		result, err := r.call(ctx, ethereum.CallMsg{To: &resolverAddr, Data: data})
+		if err != nil {
+			return nil, fmt.Errorf("failed to call addr: %w", err)
+		}
+		if len(result) > 0 {
+			if err := r.resolverABI.UnpackIntoInterface(&record.Address, "addr", result); err != nil {
+				return nil, fmt.Errorf("failed to unpack address: %w", err)
+			}
+		}
+	}
+
+	labels := strings.Split(name, ".")
+	if len(labels) == 2 {
+		// .eth second-level names are ERC-721 tokens of the registrar, keyed
+		// by the label hash. ownerOf reverts once the name has expired.
+		labelHash := new(big.Int).SetBytes(crypto.Keccak256([]byte(labels[0])))
+		expires, err := r.callToken(ctx, baseRegistrarAddress, "nameExpires", labelHash)
+		if err != nil {
+			return nil, err
+		}
+		if expiry := expires.(*big.Int); expiry.Sign() > 0 {
+			record.Expiry = time.Unix(expiry.Int64(), 0).UTC()
+		}
+		if record.Expiry.After(time.Now()) {
+			owner, err := r.callToken(ctx, baseRegistrarAddress, "ownerOf", labelHash)
+			if err != nil {
+				return nil, err
+			}
+			record.Owner = owner.(common.Address)
+		}
+	} else {
+		data, err := r.registryABI.Pack("owner", node)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack owner call: %w", err)
+		}
+		result, err := r.call(ctx, ethereum.CallMsg{To: &registryAddress, Data: data})
+		if err != nil {
+			return nil, fmt.Errorf("failed to call owner: %w", err)
+		}
+		if len(result) > 0 {
+			if err := r.registryABI.UnpackIntoInterface(&record.Owner, "owner", result); err != nil {
+				return nil, fmt.Errorf("failed to unpack owner: %w", err)
+			}
+		}
+	}
+
+	if record.Owner == nameWrapperAddress {
+		owner, err := r.callToken(ctx, nameWrapperAddress, "ownerOf", new(big.Int).SetBytes(node[:]))
+		if err != nil {
+			return nil, err
+		}
+		record.Owner = owner.(common.Address)
+	}
+	return record, nil
+}
+
+func (r *Resolver) callToken(ctx context.Context, token common.Address, method string, id *big.Int) (interface{}, error) {
+	data, err := r.tokenABI.Pack(method, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s call: %w", method, err)
+	}
+	result, err := r.call(ctx, ethereum.CallMsg{To: &token, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	values, err := r.tokenABI.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+	return values[0], nil
+}
+
+// call runs a read-only call at latest, or at the block pinned to ctx so that
+// cached results match the block they are tagged with
+func (r *Resolver) call(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	if block, ok := head.BlockFromContext(ctx); ok {
+		return r.client.CallContractAtHash(ctx, msg, common.HexToHash(block.Hash))
+	}
+	return r.client.CallContract(ctx, msg, nil)
+}
+
+func (r *Resolver) lookupResolver(ctx context.Context, node [32]byte) (common.Address, error) {
+	data, err := r.registryABI.Pack("resolver", node)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to pack resolver call: %w", err)
+	}
+	result, err := r.call(ctx, ethereum.CallMsg{To: &registryAddress, Data: data})
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to call resolver: %w", err)
+	}
+	if len(result) == 0 {
+		return common.Address{}, nil
+	}
+
+	var resolverAddr common.Address
+	if err := r.registryABI.UnpackIntoInterface(&resolverAddr, "resolver", result); err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack resolver address: %w", err)
+	}
+	return resolverAddr, nil
+}
+
 // NameHash implements the ENS namehash algorithm
 func NameHash(name string) [32]byte {
 	if name == "" {
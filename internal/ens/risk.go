@@ -0,0 +1,205 @@
+package ens
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Warning codes
+const (
+	WarningZeroWidth       = "zero-width"
+	WarningMixedScript     = "mixed-script"
+	WarningConfusable      = "confusable"
+	WarningAddressMismatch = "address-mismatch"
+)
+
+// Warning is a risk found in an ENS name
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Related is the protected name involved, if any
+	Related string `json:"related,omitempty"`
+}
+
+// invisibleRunes render as nothing but make a name distinct
+var invisibleRunes = map[rune]bool{
+	'\u00ad': true, // soft hyphen
+	'\u180e': true, // Mongolian vowel separator
+	'\u200b': true, // zero width space
+	'\u200c': true, // zero width non-joiner
+	'\u200d': true, // zero width joiner
+	'\u2060': true, // word joiner
+	'\ufeff': true, // zero width no-break space
+}
+
+// confusables maps characters to the Latin letter or digit they are commonly
+// mistaken for. It covers the Cyrillic and Greek look-alikes used in
+// practice, not the full Unicode confusables table.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'ё': 'e', 'һ': 'h',
+	'і': 'i', 'ї': 'i', 'ј': 'j', 'к': 'k', 'ӏ': 'l', 'м': 'm', 'н': 'h',
+	'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'т': 't', 'ц': 'u', 'ѵ': 'v',
+	'ԝ': 'w', 'х': 'x', 'у': 'y', 'ү': 'y',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'γ': 'y',
+	// Latin extensions
+	'ɡ': 'g', 'ı': 'i', 'ɩ': 'i', 'ł': 'l', 'ø': 'o', 'ß': 'b',
+	// Digits
+	'0': 'o', '1': 'l',
+}
+
+// multiConfusables are letter sequences that render like a single letter
+var multiConfusables = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// RiskChecker flags ENS names that could impersonate protected names
+type RiskChecker struct {
+	protected map[string]string // skeleton -> protected name
+	names     map[string]bool
+}
+
+// NewRiskChecker creates a checker for the given protected names, such as
+// our own brand and well-known ENS names
+func NewRiskChecker(protected []string) *RiskChecker {
+	c := &RiskChecker{
+		protected: make(map[string]string),
+		names:     make(map[string]bool),
+	}
+	for _, name := range protected {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		c.names[name] = true
+		c.protected[Skeleton(name)] = name
+	}
+	return c
+}
+
+// Check inspects a name without touching the network. If resolve is given,
+// confusable names are also compared with the address of the protected name
+// they imitate.
+func (c *RiskChecker) Check(ctx context.Context, name, address string, resolve func(ctx context.Context, name string) (string, error)) []Warning {
+	name = strings.ToLower(strings.TrimSpace(name))
+	warnings := []Warning{}
+
+	var invisible []string
+	for _, r := range name {
+		if invisibleRunes[r] {
+			invisible = append(invisible, fmt.Sprintf("U+%04X", r))
+		}
+	}
+	if len(invisible) > 0 {
+		warnings = append(warnings, Warning{
+			Code:    WarningZeroWidth,
+			Message: fmt.Sprintf("name contains invisible characters: %s", strings.Join(invisible, ", ")),
+		})
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if scripts := labelScripts(label); len(scripts) > 1 {
+			warnings = append(warnings, Warning{
+				Code:    WarningMixedScript,
+				Message: fmt.Sprintf("label %q mixes %s scripts", label, strings.Join(scripts, " and ")),
+			})
+		}
+	}
+
+	if c.names[name] {
+		return warnings
+	}
+	protected, ok := c.protected[Skeleton(name)]
+	if !ok {
+		return warnings
+	}
+	warnings = append(warnings, Warning{
+		Code:    WarningConfusable,
+		Message: fmt.Sprintf("name is visually confusable with %s", protected),
+		Related: protected,
+	})
+
+	if resolve != nil && address != "" {
+		protectedAddress, err := resolve(ctx, protected)
+		if err == nil && !strings.EqualFold(protectedAddress, address) {
+			warnings = append(warnings, Warning{
+				Code:    WarningAddressMismatch,
+				Message: fmt.Sprintf("name resolves to %s but %s resolves to %s", address, protected, protectedAddress),
+				Related: protected,
+			})
+		}
+	}
+	return warnings
+}
+
+// Skeleton reduces a name to the form it is visually confused with:
+// lowercased, without invisible characters, with fullwidth forms and known
+// homoglyphs replaced by their Latin counterparts
+func Skeleton(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if invisibleRunes[r] {
+			continue
+		}
+		// Fullwidth ASCII variants
+		if r >= '！' && r <= '～' {
+			r = unicode.ToLower(r - 0xfee0)
+		}
+		if mapped, ok := confusables[r]; ok {
+			r = mapped
+		}
+		// i and l are indistinguishable in many sans-serif fonts
+		if r == 'i' {
+			r = 'l'
+		}
+		b.WriteRune(r)
+	}
+	return multiConfusables.Replace(b.String())
+}
+
+var scriptTables = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Greek", unicode.Greek},
+	{"Armenian", unicode.Armenian},
+	{"Hebrew", unicode.Hebrew},
+	{"Arabic", unicode.Arabic},
+	{"Han", unicode.Han},
+	{"Hiragana", unicode.Hiragana},
+	{"Katakana", unicode.Katakana},
+	{"Hangul", unicode.Hangul},
+	{"Thai", unicode.Thai},
+	{"Devanagari", unicode.Devanagari},
+}
+
+// labelScripts returns the scripts of the letters in a label. Digits,
+// punctuation and emoji belong to no script. Han with Hiragana or Katakana
+// is ordinary Japanese and counted as one script.
+func labelScripts(label string) []string {
+	seen := make(map[string]bool)
+	var scripts []string
+	for _, r := range label {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for _, s := range scriptTables {
+			if unicode.Is(s.table, r) {
+				name := s.name
+				if name == "Hiragana" || name == "Katakana" {
+					name = "Han"
+				}
+				if !seen[name] {
+					seen[name] = true
+					scripts = append(scripts, name)
+				}
+				break
+			}
+		}
+	}
+	return scripts
+}
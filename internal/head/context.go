@@ -0,0 +1,17 @@
+package head
+
+import "context"
+
+type blockContextKey struct{}
+
+// WithBlock pins the chain reads made with ctx to block, so that a result
+// and the block it is tagged with agree
+func WithBlock(ctx context.Context, block Block) context.Context {
+	return context.WithValue(ctx, blockContextKey{}, block)
+}
+
+// BlockFromContext returns the block reads are pinned to, if any
+func BlockFromContext(ctx context.Context) (Block, bool) {
+	block, ok := ctx.Value(blockContextKey{}).(Block)
+	return block, ok
+}
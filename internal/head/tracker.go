@@ -0,0 +1,382 @@
+package head
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+)
+
+const (
+	defaultPollInterval = 12 * time.Second
+	defaultWindow       = 128
+	// maxOrphaned bounds the hashes of replaced blocks remembered per chain
+	maxOrphaned = 1024
+	pollTimeout = 10 * time.Second
+	// staleAfter is how many poll intervals a head is used without being
+	// read again
+	staleAfter = 3
+)
+
+// Status classifies a block against the tracked chain
+type Status int
+
+const (
+	// StatusUnknown blocks are outside the tracked window, or ahead of the
+	// tracked head
+	StatusUnknown Status = iota
+	// StatusCanonical blocks are on the current chain but not yet final
+	StatusCanonical
+	// StatusFinalized blocks are at or below the finalized block and can no
+	// longer be reorganised
+	StatusFinalized
+	// StatusReorged blocks were replaced by a reorg
+	StatusReorged
+)
+
+// Source reads block headers; *ethclient.Client implements it
+type Source interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Block identifies the block a result was computed at
+type Block struct {
+	Number uint64 `json:"number"`
+	Hash   string `json:"hash"`
+}
+
+// Reorg is a change of the canonical chain below the previous head
+type Reorg struct {
+	Chain string `json:"chain"`
+	// Fork is the first block that may have been replaced. Reorgs deeper
+	// than the window are reported as forking at its lower edge.
+	Fork       uint64    `json:"fork"`
+	Depth      uint64    `json:"depth"`
+	OldHead    Block     `json:"oldHead"`
+	NewHead    Block     `json:"newHead"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// State is the tracked head of one chain
+type State struct {
+	Chain     string     `json:"chain"`
+	Head      *Block     `json:"head,omitempty"`
+	Finalized *Block     `json:"finalized,omitempty"`
+	Reorgs    int        `json:"reorgs"`
+	LastReorg *Reorg     `json:"lastReorg,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+}
+
+type Config struct {
+	// PollInterval is how often the latest and finalized blocks are read
+	PollInterval time.Duration
+	// Window is how many recent block hashes are kept to detect reorgs. It
+	// should exceed the distance between the head and the finalized block.
+	Window uint64
+}
+
+type chainState struct {
+	source    Source
+	head      *Block
+	finalized *Block
+	// hashes holds the canonical hash of the blocks in the window
+	hashes map[uint64]string
+	// orphaned holds the hashes of blocks replaced by reorgs, oldest first
+	orphaned  map[string]bool
+	orphanLog []string
+	reorgs    int
+	lastReorg *Reorg
+	updatedAt *time.Time
+	lastError string
+}
+
+// Tracker follows the latest and finalized blocks of every chain it tracks
+// and detects reorgs. One tracker is shared by all chain validators.
+type Tracker struct {
+	cfg Config
+
+	mu     sync.RWMutex
+	chains map[string]*chainState
+}
+
+func NewTracker(cfg Config) *Tracker {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.Window == 0 {
+		cfg.Window = defaultWindow
+	}
+	return &Tracker{
+		cfg:    cfg,
+		chains: make(map[string]*chainState),
+	}
+}
+
+// Track adds a chain whose headers are read from source
+func (t *Tracker) Track(chain string, source Source) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, exists := t.chains[chain]; exists {
+		return fmt.Errorf("chain %s is already tracked", chain)
+	}
+	t.chains[chain] = &chainState{
+		source:   source,
+		hashes:   make(map[uint64]string),
+		orphaned: make(map[string]bool),
+	}
+	return nil
+}
+
+// Run polls every tracked chain every interval until ctx is cancelled
+func (t *Tracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		t.PollAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PollAll reads the head of every tracked chain once
+func (t *Tracker) PollAll(ctx context.Context) {
+	t.mu.RLock()
+	chains := make(map[string]*chainState, len(t.chains))
+	for name, st := range t.chains {
+		chains[name] = st
+	}
+	t.mu.RUnlock()
+
+	for name, st := range chains {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := t.poll(ctx, name, st); err != nil {
+			logger.Warn("Failed to read chain head",
+				zap.String("chain", name),
+				zap.Error(err))
+			t.mu.Lock()
+			st.lastError = err.Error()
+			t.mu.Unlock()
+		}
+	}
+}
+
+// Head returns the latest block seen on chain. A head that could not be
+// read again for several poll intervals is not returned, so that lookups are
+// not pinned to an old block while the node is unreachable.
+func (t *Tracker) Head(chain string) (Block, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	st, ok := t.chains[chain]
+	if !ok || st.head == nil || time.Since(*st.updatedAt) > staleAfter*t.cfg.PollInterval {
+		return Block{}, false
+	}
+	return *st.head, true
+}
+
+// Status classifies b against the canonical chain as far as it is tracked
+func (t *Tracker) Status(chain string, b Block) Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	st, ok := t.chains[chain]
+	if !ok || st.head == nil {
+		return StatusUnknown
+	}
+	if st.orphaned[b.Hash] {
+		return StatusReorged
+	}
+	if hash, ok := st.hashes[b.Number]; ok && hash != b.Hash {
+		return StatusReorged
+	}
+	if st.finalized != nil && b.Number <= st.finalized.Number {
+		return StatusFinalized
+	}
+	if _, ok := st.hashes[b.Number]; ok {
+		return StatusCanonical
+	}
+	return StatusUnknown
+}
+
+// States returns the tracked state of every chain
+func (t *Tracker) States() []State {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	states := make([]State, 0, len(t.chains))
+	for name, st := range t.chains {
+		states = append(states, State{
+			Chain:     name,
+			Head:      st.head,
+			Finalized: st.finalized,
+			Reorgs:    st.reorgs,
+			LastReorg: st.lastReorg,
+			UpdatedAt: st.updatedAt,
+			LastError: st.lastError,
+		})
+	}
+	return states
+}
+
+// poll reads the latest and finalized blocks of one chain. Only the polling
+// goroutine writes a chain's state, so it reads it without the lock.
+func (t *Tracker) poll(ctx context.Context, name string, st *chainState) error {
+	ctx, cancel := context.WithTimeout(ctx, pollTimeout)
+	defer cancel()
+
+	latest, err := st.source.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Nodes without the finalized tag leave every block non-final
+	finalized, err := st.source.HeaderByNumber(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber)))
+	if err != nil {
+		logger.Debug("Failed to read finalized block",
+			zap.String("chain", name),
+			zap.Error(err))
+		finalized = nil
+	}
+
+	var reorg *Reorg
+	if st.head != nil && latest.Hash().Hex() != st.head.Hash {
+		if known, ok := st.hashes[latest.Number.Uint64()]; latest.Number.Uint64() < st.head.Number && (!ok || known == latest.Hash().Hex()) {
+			// A load-balanced node behind the one seen last; the head is
+			// still current
+			now := time.Now().UTC()
+			t.mu.Lock()
+			st.updatedAt = &now
+			t.mu.Unlock()
+			return nil
+		}
+		fork, replaced, err := t.findFork(ctx, st, latest)
+		if err != nil {
+			return err
+		}
+		if replaced {
+			reorg = &Reorg{
+				Chain:      name,
+				Fork:       fork,
+				Depth:      st.head.Number - fork + 1,
+				OldHead:    *st.head,
+				NewHead:    blockOf(latest),
+				DetectedAt: time.Now().UTC(),
+			}
+		}
+	}
+
+	now := time.Now().UTC()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if reorg != nil {
+		for number, hash := range st.hashes {
+			if number >= reorg.Fork {
+				st.orphan(hash)
+				delete(st.hashes, number)
+			}
+		}
+		st.reorgs++
+		st.lastReorg = reorg
+		logger.Warn("Chain reorg detected",
+			zap.String("chain", name),
+			zap.Uint64("fork", reorg.Fork),
+			zap.Uint64("depth", reorg.Depth),
+			zap.String("oldHead", reorg.OldHead.Hash),
+			zap.String("newHead", reorg.NewHead.Hash))
+	}
+
+	head := blockOf(latest)
+	st.head = &head
+	st.hashes[head.Number] = head.Hash
+	if head.Number > 0 {
+		st.hashes[head.Number-1] = latest.ParentHash.Hex()
+	}
+	for number := range st.hashes {
+		if number+t.cfg.Window <= head.Number {
+			delete(st.hashes, number)
+		}
+	}
+	if finalized != nil {
+		block := blockOf(finalized)
+		st.finalized = &block
+	}
+	st.updatedAt = &now
+	st.lastError = ""
+	return nil
+}
+
+// findFork compares the new chain with the tracked hashes, walking back from
+// the old head. It returns the first block that may have been replaced, and
+// false if latest only extends the old head. Blocks skipped between polls
+// have no tracked hash, so the fork is placed after the last block known to
+// match.
+func (t *Tracker) findFork(ctx context.Context, st *chainState, latest *types.Header) (uint64, bool, error) {
+	canonical := func(number uint64) (string, error) {
+		switch number {
+		case latest.Number.Uint64():
+			return latest.Hash().Hex(), nil
+		case latest.Number.Uint64() - 1:
+			return latest.ParentHash.Hex(), nil
+		}
+		header, err := st.source.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return "", err
+		}
+		return header.Hash().Hex(), nil
+	}
+
+	var lowest uint64
+	if st.head.Number >= t.cfg.Window {
+		lowest = st.head.Number - t.cfg.Window + 1
+	}
+	for number := min(latest.Number.Uint64(), st.head.Number); number >= lowest; number-- {
+		known, ok := st.hashes[number]
+		if ok {
+			hash, err := canonical(number)
+			if err != nil {
+				return 0, false, err
+			}
+			if hash == known {
+				return number + 1, number+1 <= st.head.Number, nil
+			}
+		}
+		if number == 0 {
+			break
+		}
+	}
+	// Deeper than the window
+	return lowest, true, nil
+}
+
+// orphan remembers the hash of a replaced block, forgetting the oldest past
+// maxOrphaned
+func (st *chainState) orphan(hash string) {
+	if st.orphaned[hash] {
+		return
+	}
+	st.orphaned[hash] = true
+	st.orphanLog = append(st.orphanLog, hash)
+	if len(st.orphanLog) > maxOrphaned {
+		delete(st.orphaned, st.orphanLog[0])
+		st.orphanLog = st.orphanLog[1:]
+	}
+}
+
+func blockOf(header *types.Header) Block {
+	return Block{Number: header.Number.Uint64(), Hash: header.Hash().Hex()}
+}
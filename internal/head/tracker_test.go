@@ -0,0 +1,283 @@
+package head
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("production")
+	os.Exit(m.Run())
+}
+
+// fakeChain is a Source serving a chain of headers. Blocks are replaced by
+// rebuilding them on another branch.
+type fakeChain struct {
+	mu     sync.Mutex
+	blocks []*types.Header
+	// latest is the head the node reports, which may trail the blocks it
+	// knows when it lags behind
+	latest    uint64
+	finalized int64
+}
+
+func newFakeChain(length int) *fakeChain {
+	f := &fakeChain{finalized: -1}
+	f.build(0, length, "main")
+	return f
+}
+
+// build replaces blocks from number on with count blocks of branch and
+// makes the last one the head
+func (f *fakeChain) build(from uint64, count int, branch string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.blocks = f.blocks[:from]
+	for n := from; n < from+uint64(count); n++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(n), Extra: []byte(branch)}
+		if n > 0 {
+			header.ParentHash = f.blocks[n-1].Hash()
+		}
+		f.blocks = append(f.blocks, header)
+	}
+	f.latest = uint64(len(f.blocks) - 1)
+}
+
+func (f *fakeChain) block(number uint64) Block {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return blockOf(f.blocks[number])
+}
+
+func (f *fakeChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case number == nil:
+		return f.blocks[f.latest], nil
+	case number.Int64() == int64(rpc.FinalizedBlockNumber):
+		if f.finalized < 0 {
+			return nil, errors.New("finalized block not supported")
+		}
+		return f.blocks[f.finalized], nil
+	case number.Uint64() > f.latest:
+		return nil, errors.New("block not found")
+	}
+	return f.blocks[number.Uint64()], nil
+}
+
+func TestTrackerPoll(t *testing.T) {
+	tests := []struct {
+		name   string
+		window uint64
+		// length is the number of blocks before the change
+		length int
+		change func(f *fakeChain)
+		// wantHead is the tracked head after the change
+		wantHead  uint64
+		wantReorg *Reorg
+		// wantStatus maps blocks seen before the change to their status
+		// after it
+		wantStatus map[uint64]Status
+	}{
+		{
+			name:   "extension",
+			window: 16,
+			length: 11,
+			change: func(f *fakeChain) {
+				f.build(11, 3, "main")
+			},
+			wantHead: 13,
+			wantStatus: map[uint64]Status{
+				10: StatusCanonical,
+				9:  StatusCanonical,
+			},
+		},
+		{
+			name:   "same-height replacement",
+			window: 16,
+			length: 11,
+			change: func(f *fakeChain) {
+				f.build(10, 1, "uncle")
+			},
+			wantHead:  10,
+			wantReorg: &Reorg{Fork: 10, Depth: 1},
+			wantStatus: map[uint64]Status{
+				10: StatusReorged,
+				9:  StatusCanonical,
+			},
+		},
+		{
+			name:   "shallow reorg onto a longer branch",
+			window: 16,
+			length: 11,
+			change: func(f *fakeChain) {
+				f.build(8, 5, "fork")
+			},
+			wantHead:  12,
+			wantReorg: &Reorg{Fork: 8, Depth: 3},
+			wantStatus: map[uint64]Status{
+				10: StatusReorged,
+				8:  StatusReorged,
+				7:  StatusCanonical,
+			},
+		},
+		{
+			name:   "deep reorg beyond the window",
+			window: 4,
+			length: 21,
+			change: func(f *fakeChain) {
+				f.build(10, 12, "fork")
+			},
+			wantHead: 21,
+			// Reported as forking at the lowest tracked block
+			wantReorg: &Reorg{Fork: 17, Depth: 4},
+			wantStatus: map[uint64]Status{
+				20: StatusReorged,
+				17: StatusReorged,
+				// Forgotten before the change
+				10: StatusUnknown,
+			},
+		},
+		{
+			name:   "lagging load-balanced node",
+			window: 16,
+			length: 13,
+			change: func(f *fakeChain) {
+				f.latest = 10
+			},
+			wantHead: 12,
+			wantStatus: map[uint64]Status{
+				12: StatusCanonical,
+				10: StatusCanonical,
+			},
+		},
+		{
+			name:   "lagging node on another branch",
+			window: 16,
+			length: 13,
+			change: func(f *fakeChain) {
+				f.build(10, 1, "fork")
+			},
+			wantHead:  10,
+			wantReorg: &Reorg{Fork: 10, Depth: 3},
+			wantStatus: map[uint64]Status{
+				12: StatusReorged,
+				10: StatusReorged,
+				9:  StatusCanonical,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeChain(tt.length)
+			tracker := NewTracker(Config{Window: tt.window})
+			if err := tracker.Track("ethereum", f); err != nil {
+				t.Fatalf("Track: %v", err)
+			}
+
+			// Poll every block up to the initial head, as a tracker
+			// running since genesis would have
+			full := f.latest
+			for n := uint64(0); n <= full; n++ {
+				f.latest = n
+				tracker.PollAll(context.Background())
+			}
+			before := make(map[uint64]Block)
+			for n := range tt.wantStatus {
+				before[n] = f.block(n)
+			}
+			oldHead := f.block(full)
+
+			tt.change(f)
+			tracker.PollAll(context.Background())
+
+			head, ok := tracker.Head("ethereum")
+			if !ok || head.Number != tt.wantHead {
+				t.Fatalf("head = %+v (%v), want block %d", head, ok, tt.wantHead)
+			}
+			state := tracker.States()[0]
+			if state.LastError != "" {
+				t.Fatalf("poll failed: %s", state.LastError)
+			}
+
+			switch {
+			case tt.wantReorg == nil && state.LastReorg != nil:
+				t.Errorf("unexpected reorg %+v", state.LastReorg)
+			case tt.wantReorg != nil && state.LastReorg == nil:
+				t.Errorf("reorg not detected")
+			case tt.wantReorg != nil:
+				got := state.LastReorg
+				if got.Fork != tt.wantReorg.Fork || got.Depth != tt.wantReorg.Depth {
+					t.Errorf("reorg at %d of depth %d, want %d of depth %d", got.Fork, got.Depth, tt.wantReorg.Fork, tt.wantReorg.Depth)
+				}
+				if got.OldHead != oldHead || got.NewHead != head {
+					t.Errorf("reorg from %+v to %+v, want %+v to %+v", got.OldHead, got.NewHead, oldHead, head)
+				}
+				if state.Reorgs != 1 {
+					t.Errorf("counted %d reorgs, want 1", state.Reorgs)
+				}
+			}
+
+			for n, want := range tt.wantStatus {
+				if got := tracker.Status("ethereum", before[n]); got != want {
+					t.Errorf("status of block %d = %d, want %d", n, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTrackerFinalized(t *testing.T) {
+	f := newFakeChain(11)
+	f.finalized = 6
+	tracker := NewTracker(Config{Window: 16})
+	if err := tracker.Track("ethereum", f); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	tracker.PollAll(context.Background())
+
+	tests := []struct {
+		block Block
+		want  Status
+	}{
+		{f.block(10), StatusCanonical},
+		{f.block(9), StatusCanonical},
+		{f.block(6), StatusFinalized},
+		{f.block(2), StatusFinalized},
+		// Ahead of the head
+		{Block{Number: 11, Hash: "0x01"}, StatusUnknown},
+		// Another hash at a tracked height
+		{Block{Number: 10, Hash: "0x01"}, StatusReorged},
+	}
+	for _, tt := range tests {
+		if got := tracker.Status("ethereum", tt.block); got != tt.want {
+			t.Errorf("status of block %d = %d, want %d", tt.block.Number, got, tt.want)
+		}
+	}
+	if got := tracker.Status("polygon", f.block(10)); got != StatusUnknown {
+		t.Errorf("status on an untracked chain = %d, want unknown", got)
+	}
+}
+
+func TestTrackRejectsDuplicates(t *testing.T) {
+	tracker := NewTracker(Config{})
+	if err := tracker.Track("ethereum", newFakeChain(1)); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if err := tracker.Track("ethereum", newFakeChain(1)); err == nil {
+		t.Error("tracked the same chain twice")
+	}
+}
@@ -0,0 +1,31 @@
+package factory
+
+import (
+	"fmt"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/config"
+	cachefactory "github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/factory"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels/file"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels/memory"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels/redis"
+)
+
+// NewStore creates a new label store based on configuration
+func NewStore(cfg *config.Config) (labels.Store, error) {
+	switch cfg.Labels.StoreType {
+	case "redis":
+		redisConfig := cachefactory.RedisConfig(cfg)
+		redisConfig.Prefix = cfg.Labels.KeyPrefix
+		return redis.NewRedisStore(redisConfig)
+	case "file":
+		if cfg.Labels.FilePath == "" {
+			return nil, fmt.Errorf("LABELS_FILE_PATH is required for the file label store")
+		}
+		return file.NewFileStore(cfg.Labels.FilePath)
+	case "memory":
+		return memory.NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unsupported label store type: %s", cfg.Labels.StoreType)
+	}
+}
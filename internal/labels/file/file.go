@@ -0,0 +1,134 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels/memory"
+)
+
+// FileStore keeps labels in memory and persists every change to a JSON file
+type FileStore struct {
+	path  string
+	mu    sync.Mutex
+	store *memory.MemoryStore
+	// owners tracks which owners have labels, for writing the snapshot
+	owners map[string]map[string]bool
+}
+
+// snapshot is the on-disk format: owner -> labels
+type snapshot map[string][]*labels.Label
+
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path:   path,
+		store:  memory.NewMemoryStore(),
+		owners: make(map[string]map[string]bool),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read label file: %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse label file: %w", err)
+	}
+	for owner, list := range snap {
+		for _, l := range list {
+			if err := s.store.Put(context.Background(), owner, l); err != nil {
+				return nil, err
+			}
+			s.track(owner, l.Chain)
+		}
+	}
+	return s, nil
+}
+
+func (s *FileStore) Get(ctx context.Context, owner, chain, address string) (*labels.Label, error) {
+	return s.store.Get(ctx, owner, chain, address)
+}
+
+func (s *FileStore) List(ctx context.Context, owner, chain string) ([]*labels.Label, error) {
+	return s.store.List(ctx, owner, chain)
+}
+
+func (s *FileStore) Put(ctx context.Context, owner string, label *labels.Label) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.store.Put(ctx, owner, label); err != nil {
+		return err
+	}
+	s.track(owner, label.Chain)
+	return s.persist(ctx)
+}
+
+func (s *FileStore) Delete(ctx context.Context, owner, chain, address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.store.Delete(ctx, owner, chain, address); err != nil {
+		return err
+	}
+	return s.persist(ctx)
+}
+
+func (s *FileStore) Close() error {
+	return nil
+}
+
+func (s *FileStore) track(owner, chain string) {
+	if s.owners[owner] == nil {
+		s.owners[owner] = make(map[string]bool)
+	}
+	s.owners[owner][chain] = true
+}
+
+// persist writes the full snapshot to a temporary file and renames it over
+// the previous one, so a crash never leaves a half-written file
+func (s *FileStore) persist(ctx context.Context) error {
+	snap := make(snapshot)
+	for owner, chains := range s.owners {
+		for chain := range chains {
+			list, err := s.store.List(ctx, owner, chain)
+			if err != nil {
+				return err
+			}
+			snap[owner] = append(snap[owner], list...)
+		}
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write label file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write label file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write label file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write label file: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,101 @@
+package labels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Categories
+const (
+	CategoryExchange = "exchange"
+	CategoryBridge   = "bridge"
+	CategoryTreasury = "treasury"
+	CategoryScam     = "scam"
+)
+
+var categories = map[string]bool{
+	CategoryExchange: true,
+	CategoryBridge:   true,
+	CategoryTreasury: true,
+	CategoryScam:     true,
+}
+
+// ErrNotFound is returned when deleting a label that does not exist
+var ErrNotFound = errors.New("label not found")
+
+// Label describes an address in an API key's address book
+type Label struct {
+	Chain     string    `json:"chain"`
+	Address   string    `json:"address"`
+	Label     string    `json:"label"`
+	Category  string    `json:"category,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Notes     string    `json:"notes,omitempty"`
+	Team      string    `json:"team,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Validate checks the label's fields
+func (l *Label) Validate() error {
+	if strings.TrimSpace(l.Label) == "" {
+		return fmt.Errorf("label is required")
+	}
+	if l.Category != "" && !categories[l.Category] {
+		return fmt.Errorf("unknown category %q: expected exchange, bridge, treasury or scam", l.Category)
+	}
+	return nil
+}
+
+// Store defines the interface that all label storage backends must satisfy.
+// Every operation is scoped to an owner, the caller's API key.
+type Store interface {
+	// Get returns the label for an address, or nil if there is none
+	Get(ctx context.Context, owner, chain, address string) (*Label, error)
+
+	// List returns every label the owner has on a chain
+	List(ctx context.Context, owner, chain string) ([]*Label, error)
+
+	// Put creates or replaces a label
+	Put(ctx context.Context, owner string, label *Label) error
+
+	// Delete removes a label, returning ErrNotFound if there is none
+	Delete(ctx context.Context, owner, chain, address string) error
+
+	// Close releases any resources used by the store
+	Close() error
+}
+
+// Key returns the normalized (chain, address) key labels are stored under
+func Key(chain, address string) string {
+	return strings.ToLower(chain) + ":" + strings.ToLower(address)
+}
+
+// AddressBook exposes an owner's labelled addresses as trusted
+// counterparties. Addresses categorised as scams are left out.
+type AddressBook struct {
+	store Store
+}
+
+// NewAddressBook creates an address book backed by store
+func NewAddressBook(store Store) *AddressBook {
+	return &AddressBook{store: store}
+}
+
+// Counterparties returns the owner's trusted addresses on a chain
+func (b *AddressBook) Counterparties(ctx context.Context, owner, chain string) ([]string, error) {
+	list, err := b.store.List(ctx, owner, chain)
+	if err != nil {
+		return nil, err
+	}
+	addresses := make([]string, 0, len(list))
+	for _, l := range list {
+		if l.Category != CategoryScam {
+			addresses = append(addresses, l.Address)
+		}
+	}
+	return addresses, nil
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels"
+)
+
+type MemoryStore struct {
+	labels map[string]map[string]*labels.Label
+	mu     sync.RWMutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		labels: make(map[string]map[string]*labels.Label),
+	}
+}
+
+func (s *MemoryStore) Get(ctx context.Context, owner, chain, address string) (*labels.Label, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if l, ok := s.labels[owner][labels.Key(chain, address)]; ok {
+		copied := *l
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, owner, chain string) ([]*labels.Label, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var list []*labels.Label
+	for _, l := range s.labels[owner] {
+		if strings.EqualFold(l.Chain, chain) {
+			copied := *l
+			list = append(list, &copied)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Address < list[j].Address })
+	return list, nil
+}
+
+func (s *MemoryStore) Put(ctx context.Context, owner string, label *labels.Label) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.labels[owner] == nil {
+		s.labels[owner] = make(map[string]*labels.Label)
+	}
+	copied := *label
+	s.labels[owner][labels.Key(label.Chain, label.Address)] = &copied
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, owner, chain, address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := labels.Key(chain, address)
+	if _, ok := s.labels[owner][key]; !ok {
+		return labels.ErrNotFound
+	}
+	delete(s.labels[owner], key)
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
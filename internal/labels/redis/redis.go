@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	cacheredis "github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/redis"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels"
+)
+
+// RedisStore keeps each owner's labels for a chain in one hash,
+// <prefix><owner>:<chain>, keyed by lowercase address
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore connects with the same settings as the Redis cache
+func NewRedisStore(cfg cacheredis.Config) (*RedisStore, error) {
+	client, err := cacheredis.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Test connection
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	return &RedisStore{client: client, prefix: cfg.Prefix}, nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, owner, chain, address string) (*labels.Label, error) {
+	data, err := s.client.HGet(ctx, s.hashKey(owner, chain), strings.ToLower(address)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var l labels.Label
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (s *RedisStore) List(ctx context.Context, owner, chain string) ([]*labels.Label, error) {
+	values, err := s.client.HGetAll(ctx, s.hashKey(owner, chain)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*labels.Label, 0, len(values))
+	for _, data := range values {
+		var l labels.Label
+		if err := json.Unmarshal([]byte(data), &l); err != nil {
+			return nil, err
+		}
+		list = append(list, &l)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Address < list[j].Address })
+	return list, nil
+}
+
+func (s *RedisStore) Put(ctx context.Context, owner string, label *labels.Label) error {
+	data, err := json.Marshal(label)
+	if err != nil {
+		return err
+	}
+	return s.client.HSet(ctx, s.hashKey(owner, label.Chain), strings.ToLower(label.Address), data).Err()
+}
+
+func (s *RedisStore) Delete(ctx context.Context, owner, chain, address string) error {
+	removed, err := s.client.HDel(ctx, s.hashKey(owner, chain), strings.ToLower(address)).Result()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return labels.ErrNotFound
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *RedisStore) hashKey(owner, chain string) string {
+	return s.prefix + owner + ":" + strings.ToLower(chain)
+}
@@ -0,0 +1,84 @@
+package multicall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Address is the Multicall3 deployment, identical on all major EVM chains
+const Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
+const multicall3ABI = `[{"inputs":[{"components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"aggregate3","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+var parsedABI = mustParseABI(multicall3ABI)
+
+// Caller executes a read-only contract call. chain.Validator satisfies it.
+type Caller interface {
+	CallContract(ctx context.Context, to string, data []byte) ([]byte, error)
+}
+
+// Call is a single call in a Multicall3 batch
+type Call struct {
+	Target       string
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Result is the outcome of a single call in a Multicall3 batch
+type Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+type call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Aggregate3 executes the calls in a single eth_call to Multicall3
+func Aggregate3(ctx context.Context, caller Caller, calls []Call) ([]Result, error) {
+	packed := make([]call3, len(calls))
+	for i, c := range calls {
+		packed[i] = call3{
+			Target:       common.HexToAddress(c.Target),
+			AllowFailure: c.AllowFailure,
+			CallData:     c.CallData,
+		}
+	}
+
+	data, err := parsedABI.Pack("aggregate3", packed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack aggregate3 call: %w", err)
+	}
+
+	output, err := caller.CallContract(ctx, Address, data)
+	if err != nil {
+		return nil, fmt.Errorf("multicall failed: %w", err)
+	}
+	if len(output) == 0 {
+		return nil, fmt.Errorf("multicall returned no data: Multicall3 may not be deployed on this chain")
+	}
+
+	values, err := parsedABI.Unpack("aggregate3", output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack aggregate3 result: %w", err)
+	}
+	results := *abi.ConvertType(values[0], new([]Result)).(*[]Result)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", len(results), len(calls))
+	}
+	return results, nil
+}
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
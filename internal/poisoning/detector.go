@@ -0,0 +1,276 @@
+package poisoning
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator"
+)
+
+// Counterparty sources
+const (
+	SourceSupplied    = "supplied"
+	SourceAddressBook = "address-book"
+	SourceRecent      = "recent"
+)
+
+// AddressBook provides an API key's stored, trusted counterparties
+type AddressBook interface {
+	Counterparties(ctx context.Context, owner, chain string) ([]string, error)
+}
+
+// Match is a known counterparty the candidate imitates
+type Match struct {
+	validator.Similarity
+	Source string `json:"source"`
+}
+
+// Report is the outcome of a poisoning check
+type Report struct {
+	Address string `json:"address"`
+	// Known is true when the candidate is itself one of the supplied
+	// counterparties or in the address book
+	Known bool `json:"known"`
+	// SeenBefore is true when the candidate was recently seen for the same
+	// API key. Poisoned addresses are seen too, so this is not an endorsement.
+	SeenBefore bool    `json:"seenBefore"`
+	Suspicious bool    `json:"suspicious"`
+	Score      float64 `json:"score"`
+	Matches    []Match `json:"matches"`
+}
+
+// Detector compares candidate addresses against supplied counterparties,
+// the API key's address book and the addresses recently seen per API key.
+// It makes no network calls.
+type Detector struct {
+	recent *RecentStore
+	book   AddressBook
+}
+
+// NewDetector creates a detector backed by the given recent address store
+// and, if not nil, address book
+func NewDetector(recent *RecentStore, book AddressBook) *Detector {
+	return &Detector{
+		recent: recent,
+		book:   book,
+	}
+}
+
+// Observe records an address as recently seen for an API key
+func (d *Detector) Observe(apiKey, address string) {
+	if apiKey == "" || !validator.IsValidAddress(address) {
+		return
+	}
+	d.recent.Add(apiKey, address)
+}
+
+// Check compares candidate with the supplied counterparties, apiKey's
+// address book on chain and, if includeRecent is set, with the addresses
+// recently seen for apiKey
+func (d *Detector) Check(ctx context.Context, apiKey, chain, candidate string, counterparties []string, includeRecent bool) (*Report, error) {
+	if !validator.IsValidAddress(candidate) {
+		return nil, fmt.Errorf("invalid address format")
+	}
+
+	report := &Report{
+		Address: candidate,
+		Matches: []Match{},
+	}
+
+	supplied := make(map[string]bool, len(counterparties))
+	for _, c := range counterparties {
+		if !validator.IsValidAddress(c) {
+			return nil, fmt.Errorf("invalid counterparty address %q", c)
+		}
+		supplied[strings.ToLower(c)] = true
+		if strings.EqualFold(c, candidate) {
+			report.Known = true
+		}
+	}
+	if err := d.addMatches(report, candidate, counterparties, SourceSupplied); err != nil {
+		return nil, err
+	}
+
+	if d.book != nil && apiKey != "" {
+		booked, err := d.book.Counterparties(ctx, apiKey, chain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read address book: %w", err)
+		}
+		var others []string
+		for _, address := range booked {
+			if strings.EqualFold(address, candidate) {
+				report.Known = true
+				continue
+			}
+			if !supplied[strings.ToLower(address)] {
+				supplied[strings.ToLower(address)] = true
+				others = append(others, address)
+			}
+		}
+		if err := d.addMatches(report, candidate, others, SourceAddressBook); err != nil {
+			return nil, err
+		}
+	}
+
+	if includeRecent && apiKey != "" {
+		var recent []string
+		for _, address := range d.recent.List(apiKey) {
+			if strings.EqualFold(address, candidate) {
+				report.SeenBefore = true
+				continue
+			}
+			if !supplied[strings.ToLower(address)] {
+				recent = append(recent, address)
+			}
+		}
+		if err := d.addMatches(report, candidate, recent, SourceRecent); err != nil {
+			return nil, err
+		}
+	}
+
+	// A known counterparty is trusted even if it resembles another one
+	report.Suspicious = !report.Known && len(report.Matches) > 0
+	return report, nil
+}
+
+func (d *Detector) addMatches(report *Report, candidate string, known []string, source string) error {
+	similar, err := validator.FindLookalikes(candidate, known)
+	if err != nil {
+		return err
+	}
+	for _, s := range similar {
+		report.Matches = append(report.Matches, Match{Similarity: *s, Source: source})
+		report.Score = max(report.Score, s.Score)
+	}
+	return nil
+}
+
+// recentSweepInterval is how often expired addresses are dropped across
+// all keys
+const recentSweepInterval = 10 * time.Minute
+
+// RecentStore keeps the most recently seen addresses per API key, bounded
+// by count and age. The number of keys is bounded too: once it reaches
+// maxKeys, the key that least recently added an address is dropped.
+type RecentStore struct {
+	mu      sync.Mutex
+	max     int
+	maxKeys int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	// keys holds a *recentList per key, the most recently added to first
+	keys      *list.List
+	lastSweep time.Time
+}
+
+type recentList struct {
+	key     string
+	entries []recentEntry
+}
+
+type recentEntry struct {
+	address string
+	seen    time.Time
+}
+
+// NewRecentStore creates a store keeping at most max addresses for ttl for
+// each of at most maxKeys keys
+func NewRecentStore(max, maxKeys int, ttl time.Duration) *RecentStore {
+	return &RecentStore{
+		max:       max,
+		maxKeys:   maxKeys,
+		ttl:       ttl,
+		entries:   make(map[string]*list.Element),
+		keys:      list.New(),
+		lastSweep: time.Now(),
+	}
+}
+
+// Add records address for key, moving it to the front if already present
+func (s *RecentStore) Add(key, address string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= recentSweepInterval {
+		s.sweep(now)
+	}
+
+	elem, ok := s.entries[key]
+	if ok {
+		s.keys.MoveToFront(elem)
+	} else {
+		elem = s.keys.PushFront(&recentList{key: key})
+		s.entries[key] = elem
+		if s.keys.Len() > s.maxKeys {
+			s.remove(s.keys.Back())
+		}
+	}
+
+	recent := elem.Value.(*recentList)
+	seen := recent.entries
+	for i, e := range seen {
+		if strings.EqualFold(e.address, address) {
+			seen = append(seen[:i], seen[i+1:]...)
+			break
+		}
+	}
+	seen = append([]recentEntry{{address: address, seen: now}}, seen...)
+	if len(seen) > s.max {
+		seen = seen[:s.max]
+	}
+	recent.entries = seen
+}
+
+// List returns the unexpired addresses for key, most recent first
+func (s *RecentStore) List(key string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	recent := elem.Value.(*recentList)
+	s.trim(elem, time.Now())
+	addresses := make([]string, 0, len(recent.entries))
+	for _, e := range recent.entries {
+		addresses = append(addresses, e.address)
+	}
+	return addresses
+}
+
+// sweep drops the expired addresses of every key
+func (s *RecentStore) sweep(now time.Time) {
+	for elem := s.keys.Front(); elem != nil; {
+		next := elem.Next()
+		s.trim(elem, now)
+		elem = next
+	}
+	s.lastSweep = now
+}
+
+// trim drops a key's expired addresses, and the key once none are left
+func (s *RecentStore) trim(elem *list.Element, now time.Time) {
+	recent := elem.Value.(*recentList)
+	cutoff := now.Add(-s.ttl)
+	for i, e := range recent.entries {
+		if e.seen.Before(cutoff) {
+			// Entries are ordered by recency, so everything after is older
+			recent.entries = recent.entries[:i]
+			break
+		}
+	}
+	if len(recent.entries) == 0 {
+		s.remove(elem)
+	}
+}
+
+func (s *RecentStore) remove(elem *list.Element) {
+	s.keys.Remove(elem)
+	delete(s.entries, elem.Value.(*recentList).key)
+}
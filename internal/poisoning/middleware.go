@@ -0,0 +1,19 @@
+package poisoning
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/auth"
+)
+
+// Middleware records the {address} URL parameter of each request as
+// recently seen for the caller's API key. It must run after authentication.
+func (d *Detector) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if address := chi.URLParam(r, "address"); address != "" {
+			d.Observe(auth.APIKeyFromContext(r.Context()), address)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
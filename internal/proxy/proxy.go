@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/bytecode"
+)
+
+// Proxy kinds
+const (
+	KindEIP1967       = "eip1967"
+	KindEIP1967Beacon = "eip1967-beacon"
+	KindEIP1822       = "eip1822"
+	KindZeppelinOS    = "zeppelinos"
+	KindMinimal       = "minimal-proxy"
+	KindSafe          = "safe-proxy"
+	KindDelegation    = "eip7702-delegation"
+)
+
+var (
+	// bytes32(uint256(keccak256("eip1967.proxy.implementation")) - 1)
+	implementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	// bytes32(uint256(keccak256("eip1967.proxy.beacon")) - 1)
+	beaconSlot = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+	// bytes32(uint256(keccak256("eip1967.proxy.admin")) - 1)
+	adminSlot = common.HexToHash("0xb53127684a568b3173ae13b9f8a6016e243e63b6e8ee1178d6a717850b5d6103")
+	// keccak256("PROXIABLE")
+	proxiableSlot = crypto.Keccak256Hash([]byte("PROXIABLE"))
+	// keccak256("org.zeppelinos.proxy.implementation")
+	zeppelinOSSlot = crypto.Keccak256Hash([]byte("org.zeppelinos.proxy.implementation"))
+
+	// implementation()
+	implementationSelector = common.FromHex("0x5c60da1b")
+	// masterCopy(), embedded in the Safe proxy runtime
+	masterCopySelector = common.FromHex("0xa619486e")
+)
+
+// Backend is the chain access needed to inspect a proxy
+type Backend interface {
+	GetCode(ctx context.Context, address string) ([]byte, error)
+	GetStorageAt(ctx context.Context, address string, slot [32]byte) ([]byte, error)
+	CallContract(ctx context.Context, to string, data []byte) ([]byte, error)
+}
+
+// Info describes a proxy and where it forwards calls
+type Info struct {
+	Kind           string `json:"kind"`
+	Implementation string `json:"implementation"`
+	Beacon         string `json:"beacon,omitempty"`
+	Admin          string `json:"admin,omitempty"`
+}
+
+// Detect inspects the account at address and returns its proxy information,
+// or nil if it is not a recognised proxy
+func Detect(ctx context.Context, backend Backend, address string) (*Info, error) {
+	code, err := backend.GetCode(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	return DetectCode(ctx, backend, address, code)
+}
+
+// DetectCode is Detect for callers that have already fetched the account's code
+func DetectCode(ctx context.Context, backend Backend, address string, code []byte) (*Info, error) {
+	if len(code) == 0 {
+		return nil, nil
+	}
+	if target, ok := bytecode.DelegationTarget(code); ok {
+		return &Info{Kind: KindDelegation, Implementation: target.Hex()}, nil
+	}
+	if target, ok := bytecode.MinimalProxyTarget(code); ok {
+		return &Info{Kind: KindMinimal, Implementation: target.Hex()}, nil
+	}
+
+	impl, err := readAddressSlot(ctx, backend, address, implementationSlot)
+	if err != nil {
+		return nil, err
+	}
+	if impl != nil {
+		info := &Info{Kind: KindEIP1967, Implementation: impl.Hex()}
+		if admin, err := readAddressSlot(ctx, backend, address, adminSlot); err == nil && admin != nil {
+			info.Admin = admin.Hex()
+		}
+		return info, nil
+	}
+
+	beacon, err := readAddressSlot(ctx, backend, address, beaconSlot)
+	if err != nil {
+		return nil, err
+	}
+	if beacon != nil {
+		result, err := backend.CallContract(ctx, beacon.Hex(), implementationSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query beacon implementation: %w", err)
+		}
+		if len(result) < 32 {
+			return nil, fmt.Errorf("beacon returned malformed implementation")
+		}
+		return &Info{
+			Kind:           KindEIP1967Beacon,
+			Implementation: common.BytesToAddress(result[:32]).Hex(),
+			Beacon:         beacon.Hex(),
+		}, nil
+	}
+
+	for _, candidate := range []struct {
+		kind string
+		slot common.Hash
+	}{
+		{KindEIP1822, proxiableSlot},
+		{KindZeppelinOS, zeppelinOSSlot},
+	} {
+		impl, err := readAddressSlot(ctx, backend, address, candidate.slot)
+		if err != nil {
+			return nil, err
+		}
+		if impl != nil {
+			return &Info{Kind: candidate.kind, Implementation: impl.Hex()}, nil
+		}
+	}
+
+	// Safe proxies keep the singleton in slot 0 and expose masterCopy()
+	if bytes.Contains(code, masterCopySelector) {
+		singleton, err := readAddressSlot(ctx, backend, address, common.Hash{})
+		if err != nil {
+			return nil, err
+		}
+		if singleton != nil {
+			return &Info{Kind: KindSafe, Implementation: singleton.Hex()}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// readAddressSlot returns the address held in a storage slot, or nil if the
+// slot is empty or holds more than an address
+func readAddressSlot(ctx context.Context, backend Backend, address string, slot common.Hash) (*common.Address, error) {
+	value, err := backend.GetStorageAt(ctx, address, slot)
+	if err != nil {
+		return nil, err
+	}
+	word := common.BytesToHash(value)
+	if word == (common.Hash{}) || !bytes.Equal(word[:12], make([]byte, 12)) {
+		return nil, nil
+	}
+	addr := common.BytesToAddress(word[12:])
+	return &addr, nil
+}
@@ -0,0 +1,83 @@
+package reload
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"go.uber.org/zap"
+)
+
+// checkInterval bounds how often a file is stat'ed, so that Check can be
+// called on every request
+const checkInterval = 5 * time.Second
+
+// File is a data file that is read again whenever its modification time
+// changes
+type File struct {
+	path string
+	load func() error
+
+	mu        sync.Mutex
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// NewFile reads the file at path with load. If path is empty, load is called
+// once and the file is never checked.
+func NewFile(path string, load func() error) (*File, error) {
+	f := &File{path: path, load: load}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reload reads the file now
+func (f *File) Reload() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var modTime time.Time
+	if f.path != "" {
+		info, err := os.Stat(f.path)
+		if err != nil {
+			return err
+		}
+		modTime = info.ModTime()
+	}
+	if err := f.load(); err != nil {
+		return err
+	}
+	f.modTime = modTime
+	f.checkedAt = time.Now()
+	return nil
+}
+
+// Check reads the file again if it changed. A failed reload is logged and
+// the previous contents stay in use until the file changes again. Callers
+// do not wait for a check already in progress.
+func (f *File) Check() {
+	if f.path == "" || !f.mu.TryLock() {
+		return
+	}
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(f.checkedAt) < checkInterval {
+		return
+	}
+	f.checkedAt = now
+
+	info, err := os.Stat(f.path)
+	if err != nil || info.ModTime().Equal(f.modTime) {
+		return
+	}
+	f.modTime = info.ModTime()
+	if err := f.load(); err != nil {
+		logger.Error("Failed to reload file",
+			zap.String("path", f.path),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,214 @@
+package screening
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// List kinds
+const (
+	KindSanctions = "sanctions"
+	KindDenylist  = "denylist"
+	KindAllowlist = "allowlist"
+)
+
+// anyChain indexes entries that apply on every chain
+const anyChain = "*"
+
+// chainAliases maps chain tickers used by published lists (such as the OFAC
+// SDN "Digital Currency Address - ETH" type) to registry chain names
+var chainAliases = map[string]string{
+	"eth":      "ethereum",
+	"ethereum": "ethereum",
+}
+
+// Entry is a single screened address or ENS name
+type Entry struct {
+	Address string `json:"address"`
+	Chain   string `json:"chain,omitempty"`
+	ID      string `json:"id,omitempty"`
+	Note    string `json:"note,omitempty"`
+}
+
+// List is a loaded screening list indexed by chain and normalized address
+type List struct {
+	Name    string
+	Kind    string
+	entries map[string]map[string]Entry
+	size    int
+}
+
+// LoadList reads a CSV or JSON list file. Entries without a chain use
+// defaultChain, or apply to every chain if it is empty.
+func LoadList(name, kind, path, defaultChain string) (*List, error) {
+	switch kind {
+	case KindSanctions, KindDenylist, KindAllowlist:
+	default:
+		return nil, fmt.Errorf("unknown list kind %q", kind)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		entries, err = readCSV(f)
+	case ".json":
+		entries, err = readJSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported list format %q: expected .csv or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list %s: %w", name, err)
+	}
+
+	list := &List{
+		Name:    name,
+		Kind:    kind,
+		entries: make(map[string]map[string]Entry),
+	}
+	for i, e := range entries {
+		key := normalize(e.Address)
+		if key == "" {
+			continue
+		}
+		if e.ID == "" {
+			e.ID = fmt.Sprintf("%s:%d", name, i+1)
+		}
+		chain := e.Chain
+		if chain == "" {
+			chain = defaultChain
+		}
+		chain = normalizeChain(chain)
+		if list.entries[chain] == nil {
+			list.entries[chain] = make(map[string]Entry)
+		}
+		list.entries[chain][key] = e
+		list.size++
+	}
+	return list, nil
+}
+
+// Lookup returns the entry for an address or ENS name on a chain
+func (l *List) Lookup(chain, address string) (Entry, bool) {
+	key := normalize(address)
+	if e, ok := l.entries[normalizeChain(chain)][key]; ok {
+		return e, true
+	}
+	e, ok := l.entries[anyChain][key]
+	return e, ok
+}
+
+// Len returns the number of entries in the list
+func (l *List) Len() int {
+	return l.size
+}
+
+// readCSV reads address[,chain[,id[,note]]] rows. A header row naming the
+// columns may be used to reorder them.
+func readCSV(r io.Reader) ([]Entry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := map[string]int{"address": 0, "chain": 1, "id": 2, "note": 3}
+	if containsFold(records[0], "address") {
+		columns = make(map[string]int)
+		for i, col := range records[0] {
+			columns[strings.ToLower(strings.TrimSpace(col))] = i
+		}
+		records = records[1:]
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	entries := make([]Entry, 0, len(records))
+	for _, record := range records {
+		entries = append(entries, Entry{
+			Address: field(record, "address"),
+			Chain:   field(record, "chain"),
+			ID:      field(record, "id"),
+			Note:    field(record, "note"),
+		})
+	}
+	return entries, nil
+}
+
+// readJSON reads either an array of addresses, an array of entries, or an
+// object with an "entries" array
+func readJSON(r io.Reader) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	if err := json.Unmarshal(data, &addresses); err == nil {
+		entries := make([]Entry, len(addresses))
+		for i, a := range addresses {
+			entries[i] = Entry{Address: a}
+		}
+		return entries, nil
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err == nil {
+		return entries, nil
+	}
+
+	var wrapped struct {
+		Entries []Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.Entries, nil
+}
+
+func containsFold(values []string, want string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), want) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+func normalizeChain(chain string) string {
+	chain = strings.ToLower(strings.TrimSpace(chain))
+	if chain == "" {
+		return anyChain
+	}
+	if alias, ok := chainAliases[chain]; ok {
+		return alias
+	}
+	return chain
+}
@@ -0,0 +1,142 @@
+package screening
+
+import (
+	"sync"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/reload"
+	"go.uber.org/zap"
+)
+
+// Verdict statuses
+const (
+	StatusClear      = "clear"
+	StatusSanctioned = "sanctioned"
+	StatusDenied     = "denied"
+	StatusAllowed    = "allowed"
+)
+
+// Source configures a list file
+type Source struct {
+	Name  string
+	Kind  string
+	Path  string
+	Chain string
+}
+
+// Match is a list entry that matched a screened address
+type Match struct {
+	List    string `json:"list"`
+	Kind    string `json:"kind"`
+	EntryID string `json:"entryId"`
+	Subject string `json:"subject"`
+	Note    string `json:"note,omitempty"`
+}
+
+// Verdict is the outcome of screening an address. Sanctions always win; an
+// allowlist entry overrides our own denylist but never a sanctions list.
+type Verdict struct {
+	Status  string  `json:"status"`
+	Matches []Match `json:"matches,omitempty"`
+}
+
+type loadedList struct {
+	source Source
+	file   *reload.File
+	list   *List
+}
+
+// Service screens addresses against lists that are reloaded whenever their
+// backing files change
+type Service struct {
+	mu    sync.RWMutex
+	lists []*loadedList
+}
+
+// NewService loads every configured list. With no sources every address
+// screens as clear.
+func NewService(sources []Source) (*Service, error) {
+	s := &Service{}
+	for _, source := range sources {
+		l := &loadedList{source: source}
+		file, err := reload.NewFile(source.Path, func() error { return s.load(l) })
+		if err != nil {
+			return nil, err
+		}
+		l.file = file
+		s.lists = append(s.lists, l)
+	}
+	return s, nil
+}
+
+// Screen checks the given subjects (an address and, for ENS lookups, the
+// name it was resolved from) on a chain
+func (s *Service) Screen(chain string, subjects ...string) *Verdict {
+	s.reloadIfChanged()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	verdict := &Verdict{Status: StatusClear}
+	var sanctioned, denied, allowed bool
+	for _, l := range s.lists {
+		for _, subject := range subjects {
+			if subject == "" {
+				continue
+			}
+			entry, ok := l.list.Lookup(chain, subject)
+			if !ok {
+				continue
+			}
+			verdict.Matches = append(verdict.Matches, Match{
+				List:    l.list.Name,
+				Kind:    l.list.Kind,
+				EntryID: entry.ID,
+				Subject: subject,
+				Note:    entry.Note,
+			})
+			switch l.list.Kind {
+			case KindSanctions:
+				sanctioned = true
+			case KindDenylist:
+				denied = true
+			case KindAllowlist:
+				allowed = true
+			}
+		}
+	}
+
+	switch {
+	case sanctioned:
+		verdict.Status = StatusSanctioned
+	case allowed:
+		verdict.Status = StatusAllowed
+	case denied:
+		verdict.Status = StatusDenied
+	}
+	return verdict
+}
+
+func (s *Service) load(l *loadedList) error {
+	list, err := LoadList(l.source.Name, l.source.Kind, l.source.Path, l.source.Chain)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	l.list = list
+	s.mu.Unlock()
+
+	logger.Info("Loaded screening list",
+		zap.String("list", l.source.Name),
+		zap.String("kind", l.source.Kind),
+		zap.String("path", l.source.Path),
+		zap.Int("entries", list.Len()))
+	return nil
+}
+
+func (s *Service) reloadIfChanged() {
+	for _, l := range s.lists {
+		l.file.Check()
+	}
+}
@@ -0,0 +1,96 @@
+package signatures
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FunctionMatch is a selector found in bytecode with its known signatures
+type FunctionMatch struct {
+	Selector   string   `json:"selector"`
+	Signatures []string `json:"signatures,omitempty"`
+}
+
+// EventMatch is an event topic found in bytecode with its known signatures
+type EventMatch struct {
+	Topic      string   `json:"topic"`
+	Signatures []string `json:"signatures"`
+}
+
+// InterfaceMatch reports how much of a well-known interface a contract implements
+type InterfaceMatch struct {
+	Name     string   `json:"name"`
+	Matched  int      `json:"matched"`
+	Total    int      `json:"total"`
+	Complete bool     `json:"complete"`
+	Missing  []string `json:"missing,omitempty"`
+}
+
+// Analysis is the result of matching extracted selectors and topics
+type Analysis struct {
+	Functions  []FunctionMatch  `json:"functions"`
+	Events     []EventMatch     `json:"events,omitempty"`
+	Interfaces []InterfaceMatch `json:"interfaces,omitempty"`
+}
+
+// minInterfaceCoverage is the share of an interface's functions that must be
+// present before it is reported as likely implemented
+const minInterfaceCoverage = 0.5
+
+// Analyze maps function selectors and candidate event topics (typically the
+// dispatcher PUSH4 and the PUSH32 operands of a contract) to signatures and
+// interfaces. Unknown topics are dropped since most PUSH32 values are not
+// events; unknown selectors are kept.
+func (d *Database) Analyze(selectors [][4]byte, topics []common.Hash) *Analysis {
+	analysis := &Analysis{
+		Functions: make([]FunctionMatch, 0, len(selectors)),
+	}
+
+	present := make(map[[4]byte]bool, len(selectors))
+	for _, sel := range selectors {
+		present[sel] = true
+		analysis.Functions = append(analysis.Functions, FunctionMatch{
+			Selector:   fmt.Sprintf("0x%x", sel[:]),
+			Signatures: d.Functions(sel),
+		})
+	}
+
+	for _, topic := range topics {
+		if sigs := d.Events(topic); len(sigs) > 0 {
+			analysis.Events = append(analysis.Events, EventMatch{
+				Topic:      topic.Hex(),
+				Signatures: sigs,
+			})
+		}
+	}
+
+	for _, iface := range d.interfaces {
+		if len(iface.Functions) == 0 {
+			continue
+		}
+		match := InterfaceMatch{
+			Name:  iface.Name,
+			Total: len(iface.Functions),
+		}
+		for _, sig := range iface.Functions {
+			if present[Selector(sig)] {
+				match.Matched++
+			} else {
+				match.Missing = append(match.Missing, sig)
+			}
+		}
+		if float64(match.Matched)/float64(match.Total) < minInterfaceCoverage {
+			continue
+		}
+		match.Complete = match.Matched == match.Total
+		analysis.Interfaces = append(analysis.Interfaces, match)
+	}
+
+	sort.SliceStable(analysis.Interfaces, func(i, j int) bool {
+		a, b := analysis.Interfaces[i], analysis.Interfaces[j]
+		return float64(a.Matched)/float64(a.Total) > float64(b.Matched)/float64(b.Total)
+	})
+	return analysis
+}
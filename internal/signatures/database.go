@@ -0,0 +1,217 @@
+package signatures
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/reload"
+	"go.uber.org/zap"
+)
+
+//go:embed signatures.json
+var defaultDatabase []byte
+
+var signatureRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*\([A-Za-z0-9,()\[\]]*\)$`)
+
+// Interface is a well-known contract interface described by its function
+// and event signatures
+type Interface struct {
+	Name      string   `json:"name"`
+	Functions []string `json:"functions"`
+	Events    []string `json:"events,omitempty"`
+}
+
+// Database maps function selectors and event topics to text signatures
+type Database struct {
+	functions  map[[4]byte][]string
+	events     map[common.Hash][]string
+	interfaces []Interface
+}
+
+type databaseFile struct {
+	Functions  []string    `json:"functions"`
+	Events     []string    `json:"events"`
+	Interfaces []Interface `json:"interfaces"`
+}
+
+// Selector returns the 4-byte function selector for a text signature
+func Selector(signature string) [4]byte {
+	var selector [4]byte
+	copy(selector[:], crypto.Keccak256([]byte(signature))[:4])
+	return selector
+}
+
+// Topic returns the event topic for a text signature
+func Topic(signature string) common.Hash {
+	return crypto.Keccak256Hash([]byte(signature))
+}
+
+// Load loads the bundled signature database and merges the file at path, if
+// given. Interfaces in the file replace bundled interfaces with the same name.
+func Load(path string) (*Database, error) {
+	var bundled databaseFile
+	if err := json.Unmarshal(defaultDatabase, &bundled); err != nil {
+		return nil, fmt.Errorf("failed to parse bundled signature database: %w", err)
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read signature database: %w", err)
+		}
+		var local databaseFile
+		if err := json.Unmarshal(data, &local); err != nil {
+			return nil, fmt.Errorf("failed to parse signature database %s: %w", path, err)
+		}
+		bundled.Functions = append(bundled.Functions, local.Functions...)
+		bundled.Events = append(bundled.Events, local.Events...)
+		bundled.Interfaces = mergeInterfaces(bundled.Interfaces, local.Interfaces)
+	}
+
+	db := &Database{
+		functions:  make(map[[4]byte][]string),
+		events:     make(map[common.Hash][]string),
+		interfaces: bundled.Interfaces,
+	}
+	for _, sig := range bundled.Functions {
+		if err := db.addFunction(sig); err != nil {
+			return nil, err
+		}
+	}
+	for _, sig := range bundled.Events {
+		if err := db.addEvent(sig); err != nil {
+			return nil, err
+		}
+	}
+	for _, iface := range bundled.Interfaces {
+		for _, sig := range iface.Functions {
+			if err := db.addFunction(sig); err != nil {
+				return nil, err
+			}
+		}
+		for _, sig := range iface.Events {
+			if err := db.addEvent(sig); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return db, nil
+}
+
+// Functions returns the known signatures for a function selector
+func (d *Database) Functions(selector [4]byte) []string {
+	return d.functions[selector]
+}
+
+// Events returns the known signatures for an event topic
+func (d *Database) Events(topic common.Hash) []string {
+	return d.events[topic]
+}
+
+// Interfaces returns the well-known interfaces in the database
+func (d *Database) Interfaces() []Interface {
+	return d.interfaces
+}
+
+func (d *Database) addFunction(sig string) error {
+	if !signatureRegex.MatchString(sig) {
+		return fmt.Errorf("invalid function signature %q", sig)
+	}
+	selector := Selector(sig)
+	d.functions[selector] = appendUnique(d.functions[selector], sig)
+	return nil
+}
+
+func (d *Database) addEvent(sig string) error {
+	if !signatureRegex.MatchString(sig) {
+		return fmt.Errorf("invalid event signature %q", sig)
+	}
+	topic := Topic(sig)
+	d.events[topic] = appendUnique(d.events[topic], sig)
+	return nil
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+func mergeInterfaces(base, overrides []Interface) []Interface {
+	merged := append([]Interface(nil), base...)
+	index := make(map[string]int, len(merged))
+	for i, iface := range merged {
+		index[iface.Name] = i
+	}
+	for _, iface := range overrides {
+		if i, ok := index[iface.Name]; ok {
+			merged[i] = iface
+			continue
+		}
+		index[iface.Name] = len(merged)
+		merged = append(merged, iface)
+	}
+	return merged
+}
+
+// Service serves a signature database that is reloaded whenever its
+// backing file changes
+type Service struct {
+	path string
+	file *reload.File
+	mu   sync.RWMutex
+	db   *Database
+}
+
+// NewService creates a signature database service. If path is empty only the
+// bundled database is used.
+func NewService(path string) (*Service, error) {
+	s := &Service{path: path}
+	file, err := reload.NewFile(path, s.load)
+	if err != nil {
+		return nil, err
+	}
+	s.file = file
+	return s, nil
+}
+
+// Reload re-reads the signature database from disk
+func (s *Service) Reload() error {
+	return s.file.Reload()
+}
+
+func (s *Service) load() error {
+	db, err := Load(s.path)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.db = db
+	s.mu.Unlock()
+
+	logger.Info("Loaded signature database",
+		zap.String("path", s.path),
+		zap.Int("functions", len(db.functions)),
+		zap.Int("events", len(db.events)))
+	return nil
+}
+
+// Database returns the current signature database
+func (s *Service) Database() *Database {
+	s.file.Check()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.db
+}
@@ -0,0 +1,125 @@
+package signatures
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+// ParseFunction builds an ABI method from a text signature such as
+// "swap((address,uint256)[],bytes)". Text signatures carry no parameter
+// names, so inputs are named arg0, arg1, ... and tuple fields field0, ...
+func ParseFunction(signature string) (abi.Method, error) {
+	if !signatureRegex.MatchString(signature) {
+		return abi.Method{}, fmt.Errorf("invalid function signature %q", signature)
+	}
+	open := strings.IndexByte(signature, '(')
+	name := signature[:open]
+
+	types, err := splitTypes(signature[open+1 : len(signature)-1])
+	if err != nil {
+		return abi.Method{}, fmt.Errorf("invalid function signature %q: %w", signature, err)
+	}
+
+	inputs := make(abi.Arguments, len(types))
+	for i, t := range types {
+		marshaling, err := parseType(t, fmt.Sprintf("arg%d", i))
+		if err != nil {
+			return abi.Method{}, fmt.Errorf("invalid function signature %q: %w", signature, err)
+		}
+		typ, err := abi.NewType(marshaling.Type, "", marshaling.Components)
+		if err != nil {
+			return abi.Method{}, fmt.Errorf("invalid function signature %q: %w", signature, err)
+		}
+		inputs[i] = abi.Argument{Name: marshaling.Name, Type: typ}
+	}
+
+	method := abi.NewMethod(name, name, abi.Function, "nonpayable", false, false, inputs, nil)
+	if method.Sig != signature {
+		return abi.Method{}, fmt.Errorf("function signature %q is not canonical, expected %q", signature, method.Sig)
+	}
+	return method, nil
+}
+
+// parseType converts a type such as "uint256[]" or "(address,bytes)[2]" into
+// the marshaling form accepted by abi.NewType
+func parseType(t, name string) (abi.ArgumentMarshaling, error) {
+	if !strings.HasPrefix(t, "(") {
+		return abi.ArgumentMarshaling{Name: name, Type: t}, nil
+	}
+
+	end, err := matchingParen(t)
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+	fields, err := splitTypes(t[1:end])
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+
+	components := make([]abi.ArgumentMarshaling, len(fields))
+	for i, field := range fields {
+		if components[i], err = parseType(field, fmt.Sprintf("field%d", i)); err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+	}
+	return abi.ArgumentMarshaling{
+		Name:       name,
+		Type:       "tuple" + t[end+1:],
+		Components: components,
+	}, nil
+}
+
+// splitTypes splits a comma separated type list, ignoring commas nested in tuples
+func splitTypes(list string) ([]string, error) {
+	if list == "" {
+		return nil, nil
+	}
+
+	var types []string
+	depth, start := 0, 0
+	for i, c := range list {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				types = append(types, list[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	types = append(types, list[start:])
+
+	for _, t := range types {
+		if t == "" {
+			return nil, fmt.Errorf("empty type")
+		}
+	}
+	return types, nil
+}
+
+func matchingParen(t string) (int, error) {
+	depth := 0
+	for i, c := range t {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced parentheses")
+}
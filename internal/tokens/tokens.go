@@ -0,0 +1,318 @@
+package tokens
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/multicall"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/units"
+)
+
+const erc20ABI = `[
+	{"constant":true,"inputs":[],"name":"decimals","outputs":[{"name":"","type":"uint8"}],"type":"function"},
+	{"constant":true,"inputs":[],"name":"symbol","outputs":[{"name":"","type":"string"}],"type":"function"},
+	{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"type":"function"},
+	{"constant":true,"inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"name":"allowance","outputs":[{"name":"","type":"uint256"}],"type":"function"}
+]`
+
+var (
+	parsedABI = mustParseABI(erc20ABI)
+
+	// ErrNoDecimals is returned for contracts that do not answer decimals()
+	ErrNoDecimals = errors.New("token did not answer decimals()")
+
+	// maxUint96 is used as "infinite" approval by tokens with 96-bit balances (UNI, COMP)
+	maxUint96 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 96), big.NewInt(1))
+	// unlimitedThreshold catches max-uint256 approvals that have been partially spent
+	unlimitedThreshold = new(big.Int).Lsh(big.NewInt(1), 255)
+)
+
+// Metadata describes an ERC-20 token
+type Metadata struct {
+	Token    string
+	Symbol   string
+	Decimals uint8
+	// DecimalsUnknown is set when decimals() failed; amounts are then not
+	// formatted
+	DecimalsUnknown bool `json:",omitempty"`
+}
+
+// Balance is an owner's balance of a token
+type Balance struct {
+	Metadata
+	Amount *big.Int
+	Error  string
+}
+
+// Allowance is the amount a spender may transfer on behalf of an owner
+type Allowance struct {
+	Metadata
+	Spender   string
+	Amount    *big.Int
+	Unlimited bool
+	Error     string
+}
+
+// Formatted renders the balance using the token's decimals, or returns
+// false if they are unknown
+func (b Balance) Formatted() (string, bool) {
+	if b.DecimalsUnknown {
+		return "", false
+	}
+	return units.FormatUnits(b.Amount, int(b.Decimals)), true
+}
+
+// Formatted renders the allowance using the token's decimals, or returns
+// false if they are unknown
+func (a Allowance) Formatted() (string, bool) {
+	if a.DecimalsUnknown {
+		return "", false
+	}
+	return units.FormatUnits(a.Amount, int(a.Decimals)), true
+}
+
+// MetadataCache stores token metadata between requests
+type MetadataCache interface {
+	// CachedTokenMetadata returns nil on a miss, and ErrNoDecimals for
+	// tokens cached as not answering decimals()
+	CachedTokenMetadata(ctx context.Context, chain, token string) (*Metadata, error)
+	// PinHead pins the reads made with ctx to the block StoreTokenMetadata
+	// tags entries with
+	PinHead(ctx context.Context, chain string) context.Context
+	StoreTokenMetadata(ctx context.Context, chain string, metadata *Metadata)
+}
+
+// Reader queries ERC-20 state through Multicall3
+type Reader struct {
+	caller multicall.Caller
+	abi    abi.ABI
+	chain  string
+	cache  MetadataCache
+}
+
+// NewReader creates an ERC-20 reader backed by the given caller
+func NewReader(caller multicall.Caller) *Reader {
+	return &Reader{
+		caller: caller,
+		abi:    parsedABI,
+	}
+}
+
+// NewCachedReader is NewReader with symbols and decimals cached per chain.
+// Uncached metadata is read in the same multicall as the balances or
+// allowances, which is then pinned to the block the cache tags entries with.
+// Contracts that do not answer decimals() are cached as not found.
+func NewCachedReader(caller multicall.Caller, chain string, cache MetadataCache) *Reader {
+	r := NewReader(caller)
+	r.chain = chain
+	r.cache = cache
+	return r
+}
+
+// Balances returns the owner's balance of each token in a single multicall
+func (r *Reader) Balances(ctx context.Context, owner string, tokens []string) ([]Balance, error) {
+	balanceOf, err := r.abi.Pack("balanceOf", common.HexToAddress(owner))
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, metadata, missing := r.cachedMetadata(ctx, tokens)
+	calls := r.metadataCalls(tokens, missing)
+	for _, token := range tokens {
+		calls = append(calls, multicall.Call{Target: token, AllowFailure: true, CallData: balanceOf})
+	}
+
+	results, err := multicall.Aggregate3(ctx, r.caller, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	r.decodeMetadata(metadata, missing, results)
+	r.storeMetadata(ctx, metadata, missing)
+	balances := make([]Balance, len(tokens))
+	for i := range tokens {
+		balances[i] = Balance{Metadata: metadata[i]}
+		amount, err := r.decodeUint(results[2*len(missing)+i], "balanceOf")
+		if err != nil {
+			balances[i].Error = err.Error()
+			continue
+		}
+		balances[i].Amount = amount
+	}
+	return balances, nil
+}
+
+// Allowances returns allowance(owner, spender) for every token and spender
+// combination in a single multicall
+func (r *Reader) Allowances(ctx context.Context, owner string, tokens, spenders []string) ([]Allowance, error) {
+	ctx, metadata, missing := r.cachedMetadata(ctx, tokens)
+	calls := r.metadataCalls(tokens, missing)
+	for _, token := range tokens {
+		for _, spender := range spenders {
+			data, err := r.abi.Pack("allowance", common.HexToAddress(owner), common.HexToAddress(spender))
+			if err != nil {
+				return nil, err
+			}
+			calls = append(calls, multicall.Call{Target: token, AllowFailure: true, CallData: data})
+		}
+	}
+
+	results, err := multicall.Aggregate3(ctx, r.caller, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	r.decodeMetadata(metadata, missing, results)
+	r.storeMetadata(ctx, metadata, missing)
+	allowances := make([]Allowance, 0, len(tokens)*len(spenders))
+	next := 2 * len(missing)
+	for i := range tokens {
+		for _, spender := range spenders {
+			allowance := Allowance{
+				Metadata: metadata[i],
+				Spender:  spender,
+			}
+			amount, err := r.decodeUint(results[next], "allowance")
+			next++
+			if err != nil {
+				allowance.Error = err.Error()
+			} else {
+				allowance.Amount = amount
+				allowance.Unlimited = IsUnlimited(amount)
+			}
+			allowances = append(allowances, allowance)
+		}
+	}
+	return allowances, nil
+}
+
+// IsUnlimited reports whether an allowance is effectively infinite
+func IsUnlimited(amount *big.Int) bool {
+	return amount.Cmp(unlimitedThreshold) >= 0 ||
+		amount.Cmp(maxUint96) == 0 ||
+		amount.Cmp(math.MaxBig256) == 0
+}
+
+// cachedMetadata returns the metadata of every token and the indexes of the
+// tokens whose metadata must be fetched with the other calls. If any are
+// missing from the cache, the returned context pins those calls to the
+// block the fetched metadata will be stored at.
+func (r *Reader) cachedMetadata(ctx context.Context, tokens []string) (context.Context, []Metadata, []int) {
+	metadata := make([]Metadata, len(tokens))
+	cached := make([]bool, len(tokens))
+	var wg sync.WaitGroup
+	for i, token := range tokens {
+		metadata[i].Token = token
+		if r.cache == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, token string) {
+			defer wg.Done()
+			found, err := r.cache.CachedTokenMetadata(ctx, r.chain, token)
+			switch {
+			case errors.Is(err, ErrNoDecimals):
+				metadata[i].DecimalsUnknown = true
+				cached[i] = true
+			case found != nil:
+				metadata[i].Symbol = found.Symbol
+				metadata[i].Decimals = found.Decimals
+				cached[i] = true
+			}
+		}(i, token)
+	}
+	wg.Wait()
+
+	missing := make([]int, 0, len(tokens))
+	for i := range tokens {
+		if !cached[i] {
+			missing = append(missing, i)
+		}
+	}
+	if r.cache != nil && len(missing) > 0 {
+		ctx = r.cache.PinHead(ctx, r.chain)
+	}
+	return ctx, metadata, missing
+}
+
+// storeMetadata caches the metadata fetched for the missing tokens
+func (r *Reader) storeMetadata(ctx context.Context, metadata []Metadata, missing []int) {
+	if r.cache == nil {
+		return
+	}
+	for _, i := range missing {
+		r.cache.StoreTokenMetadata(ctx, r.chain, &metadata[i])
+	}
+}
+
+// metadataCalls returns decimals() and symbol() calls for the tokens at
+// the given indexes, in that order: all decimals first, then all symbols
+func (r *Reader) metadataCalls(tokens []string, missing []int) []multicall.Call {
+	decimals, _ := r.abi.Pack("decimals")
+	symbol, _ := r.abi.Pack("symbol")
+
+	calls := make([]multicall.Call, 0, 3*len(tokens))
+	for _, i := range missing {
+		calls = append(calls, multicall.Call{Target: tokens[i], AllowFailure: true, CallData: decimals})
+	}
+	for _, i := range missing {
+		calls = append(calls, multicall.Call{Target: tokens[i], AllowFailure: true, CallData: symbol})
+	}
+	return calls
+}
+
+// decodeMetadata fills in the fetched metadata of the missing tokens. Tokens
+// whose decimals() failed are marked, rather than assumed to have none.
+func (r *Reader) decodeMetadata(metadata []Metadata, missing []int, results []multicall.Result) {
+	for n, i := range missing {
+		metadata[i].DecimalsUnknown = true
+		if res := results[n]; res.Success {
+			if values, err := r.abi.Unpack("decimals", res.ReturnData); err == nil {
+				metadata[i].Decimals = values[0].(uint8)
+				metadata[i].DecimalsUnknown = false
+			}
+		}
+		if res := results[len(missing)+n]; res.Success {
+			metadata[i].Symbol = decodeSymbol(r.abi, res.ReturnData)
+		}
+	}
+}
+
+func (r *Reader) decodeUint(res multicall.Result, method string) (*big.Int, error) {
+	if !res.Success {
+		return nil, fmt.Errorf("%s call reverted", method)
+	}
+	values, err := r.abi.Unpack(method, res.ReturnData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", method, err)
+	}
+	return values[0].(*big.Int), nil
+}
+
+// decodeSymbol handles both string symbols and the bytes32 symbols used by
+// early tokens such as MKR
+func decodeSymbol(parsed abi.ABI, data []byte) string {
+	if values, err := parsed.Unpack("symbol", data); err == nil {
+		return values[0].(string)
+	}
+	if len(data) == 32 {
+		return string(bytes.TrimRight(data, "\x00"))
+	}
+	return ""
+}
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
@@ -0,0 +1,229 @@
+package txdecode
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Transaction types
+const (
+	LegacyTxType     = types.LegacyTxType
+	AccessListTxType = types.AccessListTxType
+	DynamicFeeTxType = types.DynamicFeeTxType
+	BlobTxType       = types.BlobTxType
+	// SetCodeTxType is the EIP-7702 set-code transaction
+	SetCodeTxType = 0x04
+)
+
+// EIP-7702 authorization signing magic
+const authorizationMagic = 0x05
+
+// Authorization is a signed EIP-7702 delegation
+type Authorization struct {
+	ChainID *big.Int
+	Address common.Address
+	Nonce   uint64
+	// Authority is the account that signed the delegation, nil if recovery failed
+	Authority *common.Address
+	Error     string
+}
+
+// Transaction is a decoded, signed transaction
+type Transaction struct {
+	Type                 uint8
+	Hash                 common.Hash
+	ChainID              *big.Int
+	Protected            bool
+	Nonce                uint64
+	From                 common.Address
+	To                   *common.Address
+	Value                *big.Int
+	Gas                  uint64
+	GasPrice             *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+	MaxFeePerBlobGas     *big.Int
+	BlobHashes           []common.Hash
+	Data                 []byte
+	AccessList           types.AccessList
+	Authorizations       []Authorization
+}
+
+// Decode decodes a raw signed transaction (legacy RLP or EIP-2718 typed
+// envelope) and recovers its sender
+func Decode(raw []byte) (*Transaction, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty transaction")
+	}
+	if raw[0] == SetCodeTxType {
+		return decodeSetCode(raw)
+	}
+
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction: %w", err)
+	}
+
+	var signer types.Signer
+	if tx.Protected() {
+		signer = types.LatestSignerForChainID(tx.ChainId())
+	} else {
+		signer = types.HomesteadSigner{}
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %w", err)
+	}
+
+	decoded := &Transaction{
+		Type:       tx.Type(),
+		Hash:       tx.Hash(),
+		Protected:  tx.Protected(),
+		Nonce:      tx.Nonce(),
+		From:       from,
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Gas:        tx.Gas(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+		BlobHashes: tx.BlobHashes(),
+	}
+	if tx.Protected() {
+		decoded.ChainID = tx.ChainId()
+	}
+	switch tx.Type() {
+	case LegacyTxType, AccessListTxType:
+		decoded.GasPrice = tx.GasPrice()
+	default:
+		decoded.MaxFeePerGas = tx.GasFeeCap()
+		decoded.MaxPriorityFeePerGas = tx.GasTipCap()
+	}
+	if tx.Type() == BlobTxType {
+		decoded.MaxFeePerBlobGas = tx.BlobGasFeeCap()
+	}
+	return decoded, nil
+}
+
+// setCodeTx is the EIP-7702 payload:
+// rlp([chain_id, nonce, max_priority_fee_per_gas, max_fee_per_gas, gas_limit,
+// destination, value, data, access_list, authorization_list, y_parity, r, s])
+type setCodeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         common.Address
+	Value      *big.Int
+	Data       []byte
+	AccessList types.AccessList
+	AuthList   []setCodeAuthorization
+	V, R, S    *big.Int
+}
+
+// setCodeAuthorization is rlp([chain_id, address, nonce, y_parity, r, s])
+type setCodeAuthorization struct {
+	ChainID *big.Int
+	Address common.Address
+	Nonce   uint64
+	V       uint8
+	R, S    *big.Int
+}
+
+// decodeSetCode decodes an EIP-7702 transaction by hand; the go-ethereum
+// release we build against predates the type
+func decodeSetCode(raw []byte) (*Transaction, error) {
+	var tx setCodeTx
+	if err := rlp.DecodeBytes(raw[1:], &tx); err != nil {
+		return nil, fmt.Errorf("failed to decode set-code transaction: %w", err)
+	}
+	if len(tx.AuthList) == 0 {
+		return nil, fmt.Errorf("set-code transaction has an empty authorization list")
+	}
+
+	payload, err := rlp.EncodeToBytes([]interface{}{
+		tx.ChainID, tx.Nonce, tx.GasTipCap, tx.GasFeeCap, tx.Gas,
+		tx.To, tx.Value, tx.Data, tx.AccessList, tx.AuthList,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sigHash := crypto.Keccak256Hash([]byte{SetCodeTxType}, payload)
+
+	from, err := recoverSigner(sigHash, tx.V, tx.R, tx.S)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %w", err)
+	}
+
+	to := tx.To
+	decoded := &Transaction{
+		Type:                 SetCodeTxType,
+		Hash:                 crypto.Keccak256Hash(raw),
+		ChainID:              tx.ChainID,
+		Protected:            true,
+		Nonce:                tx.Nonce,
+		From:                 from,
+		To:                   &to,
+		Value:                tx.Value,
+		Gas:                  tx.Gas,
+		MaxFeePerGas:         tx.GasFeeCap,
+		MaxPriorityFeePerGas: tx.GasTipCap,
+		Data:                 tx.Data,
+		AccessList:           tx.AccessList,
+	}
+
+	for _, auth := range tx.AuthList {
+		decoded.Authorizations = append(decoded.Authorizations, auth.decode())
+	}
+	return decoded, nil
+}
+
+// decode recovers the authority of an authorization. An invalid signature
+// only invalidates that authorization, not the transaction.
+func (a setCodeAuthorization) decode() Authorization {
+	out := Authorization{
+		ChainID: a.ChainID,
+		Address: a.Address,
+		Nonce:   a.Nonce,
+	}
+	payload, err := rlp.EncodeToBytes([]interface{}{a.ChainID, a.Address, a.Nonce})
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	hash := crypto.Keccak256Hash([]byte{authorizationMagic}, payload)
+
+	authority, err := recoverSigner(hash, new(big.Int).SetUint64(uint64(a.V)), a.R, a.S)
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	out.Authority = &authority
+	return out
+}
+
+func recoverSigner(hash common.Hash, v, r, s *big.Int) (common.Address, error) {
+	if v == nil || r == nil || s == nil || v.BitLen() > 8 {
+		return common.Address{}, fmt.Errorf("invalid signature values")
+	}
+	yParity := byte(v.Uint64())
+	if !crypto.ValidateSignatureValues(yParity, r, s, true) {
+		return common.Address{}, fmt.Errorf("invalid signature values")
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = yParity
+
+	pub, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
@@ -0,0 +1,38 @@
+package units
+
+import (
+	"math/big"
+	"strings"
+)
+
+// EtherDecimals is the number of decimals of the native currency on EVM chains
+const EtherDecimals = 18
+
+// FormatUnits renders an integer amount with the given number of decimals,
+// e.g. FormatUnits(1500000000000000000, 18) == "1.5"
+func FormatUnits(value *big.Int, decimals int) string {
+	if value == nil {
+		return "0"
+	}
+	if decimals <= 0 {
+		return value.String()
+	}
+
+	negative := value.Sign() < 0
+	digits := new(big.Int).Abs(value).String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	whole := digits[:len(digits)-decimals]
+	fraction := strings.TrimRight(digits[len(digits)-decimals:], "0")
+
+	result := whole
+	if fraction != "" {
+		result += "." + fraction
+	}
+	if negative {
+		result = "-" + result
+	}
+	return result
+}
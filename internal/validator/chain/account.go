@@ -0,0 +1,22 @@
+package chain
+
+import "math/big"
+
+// AccountState is the state of an account at a given block
+type AccountState struct {
+	Address  string
+	Block    string
+	Balance  *big.Int
+	Nonce    uint64
+	CodeHash string
+	// Error is set by GetAccounts when the lookup of this account failed
+	Error string
+}
+
+// EmptyCodeHash is the code hash of an account without code, keccak256("")
+const EmptyCodeHash = "0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
+
+// IsContract reports whether the account has code deployed
+func (a *AccountState) IsContract() bool {
+	return a.CodeHash != "" && a.CodeHash != EmptyCodeHash
+}
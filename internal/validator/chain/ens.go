@@ -0,0 +1,14 @@
+package chain
+
+import "time"
+
+// ENSRecord is the current on-chain state of an ENS name. Addresses are
+// empty when unset.
+type ENSRecord struct {
+	Name     string
+	Resolver string
+	Address  string
+	Owner    string
+	// Expiry is zero for names that do not expire
+	Expiry time.Time
+}
@@ -1,6 +1,9 @@
 package chain
 
-import "context"
+import (
+	"context"
+	"math/big"
+)
 
 // Validator defines the interface that all chain validators must implement
 type Validator interface {
@@ -11,13 +14,44 @@ type Validator interface {
 	IsChecksumAddress(address string) bool
 
 	// ResolveENS resolves an ENS name to its Ethereum address
-	ResolveENS(name string) (string, error)
+	ResolveENS(ctx context.Context, name string) (string, error)
+
+	// ReverseResolveENS returns the verified primary ENS name of an address,
+	// or an empty string if it has none
+	ReverseResolveENS(ctx context.Context, address string) (string, error)
+
+	// LookupENS returns the current resolver, address, owner and expiry of an
+	// ENS name, bypassing any cache
+	LookupENS(ctx context.Context, name string) (*ENSRecord, error)
 
 	// IsContract checks if the given address is a contract
 	IsContract(ctx context.Context, address string) (bool, error)
 
+	// GetCode returns the runtime bytecode deployed at the given address at
+	// the latest block, or the block pinned with head.WithBlock
+	GetCode(ctx context.Context, address string) ([]byte, error)
+
+	// GetStorageAt returns the 32-byte value stored at the given slot of an
+	// account at the latest block, or the block pinned with head.WithBlock
+	GetStorageAt(ctx context.Context, address string, slot [32]byte) ([]byte, error)
+
+	// CallContract executes a read-only call against the latest block, or
+	// the block pinned with head.WithBlock
+	CallContract(ctx context.Context, to string, data []byte) ([]byte, error)
+
+	// GetAccount returns the balance, nonce and code hash of an address at the
+	// given block ("latest", another block tag, a block number or a block hash)
+	GetAccount(ctx context.Context, address string, block string) (*AccountState, error)
+
+	// GetAccounts is the batched form of GetAccount, issued as a single
+	// JSON-RPC batch request. Per-account failures are reported in Error.
+	GetAccounts(ctx context.Context, addresses []string, block string) ([]*AccountState, error)
+
 	// GetChainName returns the name of the chain this validator supports
 	GetChainName() string
+
+	// GetChainID returns the chain ID reported by the connected node
+	GetChainID() *big.Int
 }
 
 // ValidatorConstructor is a function type that creates new validators
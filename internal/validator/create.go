@@ -0,0 +1,213 @@
+package validator
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// CREATE3 variants supported by Create3Address
+const (
+	// Create3Solady is the Solady/solmate CREATE3 library called directly by the deploying contract
+	Create3Solady = "solady"
+	// Create3ZeframLou is the CREATE3Factory by ZeframLou, which namespaces salts by caller
+	Create3ZeframLou = "zeframlou"
+	// Create3CreateX is the CreateX factory with its guarded salt scheme
+	Create3CreateX = "createx"
+)
+
+// Well-known CREATE3 factory deployments (same address on all supported chains)
+const (
+	CreateXFactoryAddress   = "0xba5Ed099633D3B313e4D5F7bdc1305d3c28ba5Ed"
+	ZeframLouFactoryAddress = "0x9fBB3DF7C40Da2e5A0dE984fFE2CCB7C47cd0ABf"
+)
+
+var (
+	// keccak256 of the CREATE3 proxy init code 0x67363d3d37363d34f03d5260086018f3
+	create3ProxyInitCodeHash, _ = hex.DecodeString("21c35dbe1b344a2488cf3321d6ce542f8e9f305544ff09e4993a62319a497c1f")
+)
+
+// CreateAddress computes the address of a contract deployed with CREATE,
+// i.e. keccak256(rlp([sender, nonce]))[12:]
+func CreateAddress(sender string, nonce uint64) (string, error) {
+	from, err := decodeAddress(sender)
+	if err != nil {
+		return "", fmt.Errorf("invalid sender: %w", err)
+	}
+
+	encoded, err := rlp.EncodeToBytes([]interface{}{from, nonce})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode sender and nonce: %w", err)
+	}
+	return addressFromHash(Keccak256(encoded))
+}
+
+// Create2Address computes the address of a contract deployed with CREATE2,
+// i.e. keccak256(0xff ++ deployer ++ salt ++ keccak256(initCode))[12:]
+func Create2Address(deployer string, salt []byte, initCodeHash []byte) (string, error) {
+	from, err := decodeAddress(deployer)
+	if err != nil {
+		return "", fmt.Errorf("invalid deployer: %w", err)
+	}
+	if len(salt) != 32 {
+		return "", fmt.Errorf("salt must be 32 bytes, got %d", len(salt))
+	}
+	if len(initCodeHash) != 32 {
+		return "", fmt.Errorf("init code hash must be 32 bytes, got %d", len(initCodeHash))
+	}
+	return addressFromHash(create2(from, salt, initCodeHash))
+}
+
+// Create3Address computes the address of a contract deployed through a CREATE3
+// factory. The factory deploys a minimal proxy with CREATE2 which in turn deploys
+// the contract with CREATE at nonce 1, so the result only depends on the factory,
+// the (possibly guarded) salt and, for some variants, the caller and chain ID.
+// It returns both the final address and the intermediate proxy address.
+func Create3Address(variant, factory, sender string, salt []byte, chainID *big.Int) (string, string, error) {
+	if len(salt) != 32 {
+		return "", "", fmt.Errorf("salt must be 32 bytes, got %d", len(salt))
+	}
+
+	if factory == "" {
+		switch variant {
+		case Create3ZeframLou:
+			factory = ZeframLouFactoryAddress
+		case Create3CreateX:
+			factory = CreateXFactoryAddress
+		default:
+			return "", "", fmt.Errorf("factory address is required for %s", variant)
+		}
+	}
+	factoryAddr, err := decodeAddress(factory)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid factory: %w", err)
+	}
+
+	var effectiveSalt []byte
+	switch variant {
+	case Create3Solady:
+		effectiveSalt = salt
+	case Create3ZeframLou:
+		caller, err := decodeAddress(sender)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid sender: %w", err)
+		}
+		// keccak256(abi.encodePacked(msg.sender, salt))
+		effectiveSalt = Keccak256(append(caller, salt...))
+	case Create3CreateX:
+		effectiveSalt, err = createXGuardedSalt(sender, salt, chainID)
+		if err != nil {
+			return "", "", err
+		}
+	default:
+		return "", "", fmt.Errorf("unsupported CREATE3 variant: %s", variant)
+	}
+
+	proxy := create2(factoryAddr, effectiveSalt, create3ProxyInitCodeHash)[12:]
+
+	// The proxy deploys the contract with CREATE at nonce 1:
+	// rlp([proxy, 1]) = 0xd6 0x94 ++ proxy ++ 0x01
+	encoded := append([]byte{0xd6, 0x94}, proxy...)
+	encoded = append(encoded, 0x01)
+
+	address, err := addressFromHash(Keccak256(encoded))
+	if err != nil {
+		return "", "", err
+	}
+	proxyAddress, err := ToChecksumAddress("0x" + hex.EncodeToString(proxy))
+	if err != nil {
+		return "", "", err
+	}
+	return address, proxyAddress, nil
+}
+
+// InitCodeHash returns the keccak256 hash of contract init code
+func InitCodeHash(initCode []byte) []byte {
+	return Keccak256(initCode)
+}
+
+// DecodeHex decodes a hex string with an optional 0x prefix
+func DecodeHex(s string) ([]byte, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	return hex.DecodeString(s)
+}
+
+// createXGuardedSalt reproduces CreateX's _guard salt derivation. The first 20
+// bytes of the salt select sender protection and the 21st byte selects
+// cross-chain redeploy protection.
+func createXGuardedSalt(sender string, salt []byte, chainID *big.Int) ([]byte, error) {
+	var senderBytes []byte
+	if sender != "" {
+		var err error
+		senderBytes, err = decodeAddress(sender)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sender: %w", err)
+		}
+	}
+
+	prefix := salt[:20]
+	flag := salt[20]
+	isSender := senderBytes != nil && string(prefix) == string(senderBytes)
+	isZero := string(prefix) == string(make([]byte, 20))
+
+	switch {
+	case isSender && flag == 0x01:
+		if chainID == nil {
+			return nil, fmt.Errorf("chain ID is required for cross-chain protected salts")
+		}
+		// keccak256(abi.encode(msg.sender, block.chainid, salt))
+		return Keccak256(concat(leftPad32(senderBytes), leftPad32(chainID.Bytes()), salt)), nil
+	case isSender && flag == 0x00:
+		return Keccak256(concat(leftPad32(senderBytes), salt)), nil
+	case isSender:
+		return nil, fmt.Errorf("invalid CreateX salt: redeploy protection flag must be 0x00 or 0x01")
+	case isZero && flag == 0x01:
+		if chainID == nil {
+			return nil, fmt.Errorf("chain ID is required for cross-chain protected salts")
+		}
+		return Keccak256(concat(leftPad32(chainID.Bytes()), salt)), nil
+	case isZero && flag > 0x01:
+		return nil, fmt.Errorf("invalid CreateX salt: redeploy protection flag must be 0x00 or 0x01")
+	default:
+		// keccak256(abi.encode(salt))
+		return Keccak256(salt), nil
+	}
+}
+
+func create2(deployer, salt, initCodeHash []byte) []byte {
+	return Keccak256(concat([]byte{0xff}, deployer, salt, initCodeHash))
+}
+
+func decodeAddress(address string) ([]byte, error) {
+	if !IsValidAddress(address) {
+		return nil, fmt.Errorf("invalid ethereum address format")
+	}
+	return hex.DecodeString(address[2:])
+}
+
+func addressFromHash(hash []byte) (string, error) {
+	return ToChecksumAddress("0x" + hex.EncodeToString(hash[12:]))
+}
+
+func leftPad32(b []byte) []byte {
+	if len(b) >= 32 {
+		return b
+	}
+	padded := make([]byte, 32)
+	copy(padded[32-len(b):], b)
+	return padded
+}
+
+func concat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
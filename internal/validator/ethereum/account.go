@@ -0,0 +1,135 @@
+package ethereum
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+	"go.uber.org/zap"
+)
+
+var blockHashRegex = regexp.MustCompile("^0x[0-9a-fA-F]{64}$")
+
+func (v *EthereumValidator) GetAccount(ctx context.Context, address string, block string) (*chain.AccountState, error) {
+	states, err := v.GetAccounts(ctx, []string{address}, block)
+	if err != nil {
+		return nil, err
+	}
+	if states[0].Error != "" {
+		return nil, fmt.Errorf("%s", states[0].Error)
+	}
+	return states[0], nil
+}
+
+func (v *EthereumValidator) GetAccounts(ctx context.Context, addresses []string, block string) ([]*chain.AccountState, error) {
+	if block == "" {
+		block = "latest"
+	}
+	blockParam, err := parseBlockParam(block)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Debug("Fetching account state",
+		zap.Int("accounts", len(addresses)),
+		zap.String("block", block))
+
+	type accountCalls struct {
+		balance hexutil.Big
+		nonce   hexutil.Uint64
+		code    hexutil.Bytes
+	}
+
+	states := make([]*chain.AccountState, len(addresses))
+	results := make([]*accountCalls, len(addresses))
+	batch := make([]rpc.BatchElem, 0, len(addresses)*3)
+
+	for i, address := range addresses {
+		states[i] = &chain.AccountState{
+			Address: address,
+			Block:   block,
+		}
+		if !v.IsValidAddress(address) {
+			states[i].Error = "invalid address format"
+			continue
+		}
+
+		res := &accountCalls{}
+		results[i] = res
+		addr := common.HexToAddress(address)
+		batch = append(batch,
+			rpc.BatchElem{Method: "eth_getBalance", Args: []interface{}{addr, blockParam}, Result: &res.balance},
+			rpc.BatchElem{Method: "eth_getTransactionCount", Args: []interface{}{addr, blockParam}, Result: &res.nonce},
+			rpc.BatchElem{Method: "eth_getCode", Args: []interface{}{addr, blockParam}, Result: &res.code},
+		)
+	}
+
+	if len(batch) > 0 {
+		if err := v.client.Client().BatchCallContext(ctx, batch); err != nil {
+			logger.Error("Account state batch request failed",
+				zap.Error(err))
+			return nil, fmt.Errorf("failed to fetch account state: %w", err)
+		}
+	}
+
+	elem := 0
+	for i, res := range results {
+		if res == nil {
+			continue
+		}
+		calls := batch[elem : elem+3]
+		elem += 3
+
+		for _, call := range calls {
+			if call.Error != nil {
+				states[i].Error = call.Error.Error()
+				break
+			}
+		}
+		if states[i].Error != "" {
+			continue
+		}
+
+		states[i].Balance = (*big.Int)(&res.balance)
+		states[i].Nonce = uint64(res.nonce)
+		states[i].CodeHash = crypto.Keccak256Hash(res.code).Hex()
+	}
+
+	return states, nil
+}
+
+// parseBlockParam converts a block tag, decimal or hex block number, or block
+// hash into a JSON-RPC block parameter (EIP-1898 for hashes)
+func parseBlockParam(block string) (interface{}, error) {
+	switch strings.ToLower(block) {
+	case "latest", "earliest", "pending", "safe", "finalized":
+		return strings.ToLower(block), nil
+	}
+
+	if blockHashRegex.MatchString(block) {
+		return map[string]interface{}{"blockHash": common.HexToHash(block)}, nil
+	}
+
+	if strings.HasPrefix(block, "0x") {
+		number, err := hexutil.DecodeUint64(block)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block number %q", block)
+		}
+		return hexutil.EncodeUint64(number), nil
+	}
+
+	number, err := strconv.ParseUint(block, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block %q: expected a tag, number or hash", block)
+	}
+	return hexutil.EncodeUint64(number), nil
+}
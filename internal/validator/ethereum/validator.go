@@ -3,14 +3,17 @@ package ethereum
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"regexp"
 	"strings"
-	"time"
 
+	goethereum "github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/ens"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/head"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
 	"go.uber.org/zap"
@@ -23,8 +26,10 @@ var (
 )
 
 type EthereumValidator struct {
-	client *ethclient.Client
-	ens    *ens.Resolver
+	client  *ethclient.Client
+	ens     *ens.Resolver
+	cache   *cache.AddressCache
+	chainID *big.Int
 }
 
 func NewValidator(config map[string]interface{}) (chain.Validator, error) {
@@ -42,28 +47,37 @@ func NewValidator(config map[string]interface{}) (chain.Validator, error) {
 	}
 
 	// Test connection
-	_, err = client.ChainID(context.Background())
+	chainID, err := client.ChainID(context.Background())
 	if err != nil {
 		log.Errorf("Failed to get chain ID: %v", err)
 		return nil, fmt.Errorf("failed to verify connection: %w", err)
 	}
 
-	cacheDuration, _ := config["cache_duration"].(int64)
-	if cacheDuration == 0 {
-		cacheDuration = 3600 // 1 hour default
+	// Lookups are uncached without an address cache
+	addressCache, _ := config["address_cache"].(*cache.AddressCache)
+	if addressCache == nil {
+		log.Warn("No address cache configured; lookups will not be cached")
 	}
-	log.Debugf("Using cache duration: %d seconds", cacheDuration)
 
-	ensResolver, err := ens.NewResolver(providerURL, time.Duration(cacheDuration)*time.Second)
+	ensResolver, err := ens.NewResolver(providerURL, addressCache)
 	if err != nil {
 		log.Errorf("Failed to create ENS resolver: %v", err)
 		return nil, fmt.Errorf("failed to create ENS resolver: %w", err)
 	}
 
+	// Cached results are tagged with the head read by the shared tracker
+	if tracker, ok := config["head_tracker"].(*head.Tracker); ok && tracker != nil {
+		if err := tracker.Track("ethereum", client); err != nil {
+			return nil, fmt.Errorf("failed to track chain head: %w", err)
+		}
+	}
+
 	log.Info("Successfully initialized Ethereum validator")
 	return &EthereumValidator{
-		client: client,
-		ens:    ensResolver,
+		client:  client,
+		ens:     ensResolver,
+		cache:   addressCache,
+		chainID: chainID,
 	}, nil
 }
 
@@ -149,11 +163,11 @@ func Keccak256(data []byte) []byte {
 	return hash.Sum(nil)
 }
 
-func (v *EthereumValidator) ResolveENS(name string) (string, error) {
+func (v *EthereumValidator) ResolveENS(ctx context.Context, name string) (string, error) {
 	logger.Debug("Resolving ENS name",
 		zap.String("name", name))
 
-	result, err := v.ens.Resolve(context.Background(), name)
+	result, err := v.ens.Resolve(ctx, name)
 	if err != nil {
 		logger.Error("Failed to resolve ENS name",
 			zap.String("name", name),
@@ -172,21 +186,62 @@ func (v *EthereumValidator) ResolveENS(name string) (string, error) {
 	return result.Address.Hex(), nil
 }
 
+func (v *EthereumValidator) ReverseResolveENS(ctx context.Context, address string) (string, error) {
+	if !v.IsValidAddress(address) {
+		return "", fmt.Errorf("invalid address format")
+	}
+
+	name, err := v.ens.LookupAddress(ctx, common.HexToAddress(address))
+	if err != nil {
+		logger.Error("Failed to reverse resolve address",
+			zap.String("address", address),
+			zap.Error(err))
+		return "", err
+	}
+	return name, nil
+}
+
+func (v *EthereumValidator) LookupENS(ctx context.Context, name string) (*chain.ENSRecord, error) {
+	record, err := v.ens.Lookup(ctx, name)
+	if err != nil {
+		logger.Error("Failed to look up ENS name",
+			zap.String("name", name),
+			zap.Error(err))
+		return nil, err
+	}
+	return &chain.ENSRecord{
+		Name:     record.Name,
+		Resolver: optionalAddress(record.Resolver),
+		Address:  optionalAddress(record.Address),
+		Owner:    optionalAddress(record.Owner),
+		Expiry:   record.Expiry,
+	}, nil
+}
+
+// optionalAddress returns the checksummed address, or an empty string for
+// the zero address
+func optionalAddress(address common.Address) string {
+	if address == (common.Address{}) {
+		return ""
+	}
+	return address.Hex()
+}
+
 func (v *EthereumValidator) IsContract(ctx context.Context, address string) (bool, error) {
 	logger.Debug("Checking if address is contract",
 		zap.String("address", address))
 
-	if !v.IsValidAddress(address) {
-		logger.Warn("Invalid address format",
-			zap.String("address", address))
-		return false, fmt.Errorf("invalid address format")
+	if v.cache == nil || !v.IsValidAddress(address) {
+		return v.checkContract(ctx, address)
 	}
+	return v.cache.FetchIsContract(ctx, v.GetChainName(), address, func(ctx context.Context) (bool, error) {
+		return v.checkContract(ctx, address)
+	})
+}
 
-	code, err := v.client.CodeAt(ctx, common.HexToAddress(address), nil)
+func (v *EthereumValidator) checkContract(ctx context.Context, address string) (bool, error) {
+	code, err := v.GetCode(ctx, address)
 	if err != nil {
-		logger.Error("Failed to get code at address",
-			zap.String("address", address),
-			zap.Error(err))
 		return false, err
 	}
 
@@ -197,10 +252,85 @@ func (v *EthereumValidator) IsContract(ctx context.Context, address string) (boo
 	return isContract, nil
 }
 
+func (v *EthereumValidator) GetCode(ctx context.Context, address string) ([]byte, error) {
+	if !v.IsValidAddress(address) {
+		logger.Warn("Invalid address format",
+			zap.String("address", address))
+		return nil, fmt.Errorf("invalid address format")
+	}
+
+	var code []byte
+	var err error
+	if block, ok := head.BlockFromContext(ctx); ok {
+		code, err = v.client.CodeAtHash(ctx, common.HexToAddress(address), common.HexToHash(block.Hash))
+	} else {
+		code, err = v.client.CodeAt(ctx, common.HexToAddress(address), nil)
+	}
+	if err != nil {
+		logger.Error("Failed to get code at address",
+			zap.String("address", address),
+			zap.Error(err))
+		return nil, err
+	}
+	return code, nil
+}
+
+func (v *EthereumValidator) GetStorageAt(ctx context.Context, address string, slot [32]byte) ([]byte, error) {
+	if !v.IsValidAddress(address) {
+		return nil, fmt.Errorf("invalid address format")
+	}
+
+	var value []byte
+	var err error
+	if block, ok := head.BlockFromContext(ctx); ok {
+		value, err = v.client.StorageAtHash(ctx, common.HexToAddress(address), common.Hash(slot), common.HexToHash(block.Hash))
+	} else {
+		value, err = v.client.StorageAt(ctx, common.HexToAddress(address), common.Hash(slot), nil)
+	}
+	if err != nil {
+		logger.Error("Failed to read storage slot",
+			zap.String("address", address),
+			zap.String("slot", common.Hash(slot).Hex()),
+			zap.Error(err))
+		return nil, err
+	}
+	return value, nil
+}
+
+func (v *EthereumValidator) CallContract(ctx context.Context, to string, data []byte) ([]byte, error) {
+	if !v.IsValidAddress(to) {
+		return nil, fmt.Errorf("invalid address format")
+	}
+
+	target := common.HexToAddress(to)
+	msg := goethereum.CallMsg{
+		To:   &target,
+		Data: data,
+	}
+	var result []byte
+	var err error
+	if block, ok := head.BlockFromContext(ctx); ok {
+		result, err = v.client.CallContractAtHash(ctx, msg, common.HexToHash(block.Hash))
+	} else {
+		result, err = v.client.CallContract(ctx, msg, nil)
+	}
+	if err != nil {
+		logger.Error("Contract call failed",
+			zap.String("to", to),
+			zap.Error(err))
+		return nil, err
+	}
+	return result, nil
+}
+
 func (v *EthereumValidator) GetChainName() string {
 	return "ethereum"
 }
 
+func (v *EthereumValidator) GetChainID() *big.Int {
+	return new(big.Int).Set(v.chainID)
+}
+
 func (v *EthereumValidator) Close() error {
 	log.Info("Closing Ethereum validator")
 	v.client.Close()
@@ -0,0 +1,147 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Similarity reasons
+const (
+	// ReasonLookalike is a vanity address sharing the leading and trailing
+	// characters wallets display, with an unrelated middle
+	ReasonLookalike = "lookalike"
+	// ReasonNearDuplicate differs from a known address by a few characters,
+	// such as a typo or a single-character substitution
+	ReasonNearDuplicate = "near-duplicate"
+)
+
+const (
+	// lookalikeEdge is the minimum matching characters at each end for an
+	// address to count as a look-alike; wallets typically show 4 to 6
+	lookalikeEdge = 3
+	// lookalikeTotal catches lopsided matches such as a 6-character prefix
+	// and 1-character suffix
+	lookalikeTotal = 7
+	// nearDuplicateDistance is the largest edit distance treated as a typo
+	nearDuplicateDistance = 3
+	// maxScoredEdge is the combined edge match that scores 1.0; generating a
+	// vanity address with 10 fixed hex characters takes ~2^40 attempts
+	maxScoredEdge = 10
+)
+
+// Similarity compares a candidate address with a known address. The hex
+// digits are compared case-insensitively, without the 0x prefix.
+type Similarity struct {
+	Address     string  `json:"address"`
+	PrefixMatch int     `json:"prefixMatch"`
+	SuffixMatch int     `json:"suffixMatch"`
+	Distance    int     `json:"distance"`
+	Score       float64 `json:"score"`
+	Reason      string  `json:"reason,omitempty"`
+}
+
+// Suspicious reports whether the similarity indicates a probable poisoning
+// attempt or typo
+func (s *Similarity) Suspicious() bool {
+	return s.Reason != ""
+}
+
+// CompareAddresses measures how closely candidate imitates known. Identical
+// addresses have a distance of 0 and are never suspicious.
+func CompareAddresses(candidate, known string) (*Similarity, error) {
+	if !IsValidAddress(candidate) {
+		return nil, fmt.Errorf("invalid candidate address %q", candidate)
+	}
+	if !IsValidAddress(known) {
+		return nil, fmt.Errorf("invalid known address %q", known)
+	}
+
+	a := strings.ToLower(candidate[2:])
+	b := strings.ToLower(known[2:])
+
+	s := &Similarity{
+		Address:     known,
+		PrefixMatch: commonPrefixLen(a, b),
+		SuffixMatch: commonPrefixLen(reverse(a), reverse(b)),
+		Distance:    editDistance(a, b),
+	}
+	if s.Distance == 0 {
+		return s, nil
+	}
+
+	edge := s.PrefixMatch + s.SuffixMatch
+	edgeScore := float64(min(edge, maxScoredEdge)) / maxScoredEdge
+	typoScore := 0.0
+	if s.Distance <= nearDuplicateDistance {
+		typoScore = 1 - float64(s.Distance-1)*0.1
+	}
+	s.Score = max(edgeScore, typoScore)
+
+	switch {
+	case s.Distance <= nearDuplicateDistance:
+		s.Reason = ReasonNearDuplicate
+	case (s.PrefixMatch >= lookalikeEdge && s.SuffixMatch >= lookalikeEdge) || edge >= lookalikeTotal:
+		s.Reason = ReasonLookalike
+	}
+	return s, nil
+}
+
+// FindLookalikes compares candidate with every known address and returns
+// the suspicious matches, most similar first. Invalid known addresses and
+// exact matches are skipped.
+func FindLookalikes(candidate string, known []string) ([]*Similarity, error) {
+	if !IsValidAddress(candidate) {
+		return nil, fmt.Errorf("invalid candidate address %q", candidate)
+	}
+
+	var matches []*Similarity
+	for _, k := range known {
+		s, err := CompareAddresses(candidate, k)
+		if err != nil || !s.Suspicious() {
+			continue
+		}
+		matches = append(matches, s)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	return matches, nil
+}
+
+func commonPrefixLen(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func reverse(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// editDistance is the Levenshtein distance between two ASCII strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
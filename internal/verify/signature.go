@@ -0,0 +1,63 @@
+package verify
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// HashPersonalMessage returns the EIP-191 version 0x45 (personal_sign) hash:
+// keccak256("\x19Ethereum Signed Message:\n" + len(message) + message)
+func HashPersonalMessage(message []byte) common.Hash {
+	prefix := fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))
+	return crypto.Keccak256Hash([]byte(prefix), message)
+}
+
+// NormalizeSignature converts a 65-byte [r || s || v] signature with v in
+// {0, 1, 27, 28} or EIP-155 form, or a 64-byte EIP-2098 compact signature
+// [r || yParity·s], into the 65-byte [r || s || v] form with v in {0, 1}
+func NormalizeSignature(sig []byte) ([]byte, error) {
+	switch len(sig) {
+	case 65:
+		out := make([]byte, 65)
+		copy(out, sig)
+		v := sig[64]
+		switch {
+		case v == 0 || v == 1:
+		case v == 27 || v == 28:
+			out[64] = v - 27
+		case v >= 35:
+			out[64] = (v - 35) % 2
+		default:
+			return nil, fmt.Errorf("invalid signature recovery id v=%d", v)
+		}
+		return out, nil
+
+	case 64:
+		out := make([]byte, 65)
+		copy(out[:32], sig[:32])
+		vs := new(big.Int).SetBytes(sig[32:])
+		out[64] = byte(vs.Bit(255))
+		vs.SetBit(vs, 255, 0)
+		vs.FillBytes(out[32:64])
+		return out, nil
+
+	default:
+		return nil, fmt.Errorf("invalid signature length %d: expected 64 or 65 bytes", len(sig))
+	}
+}
+
+// RecoverSigner recovers the address that produced an ECDSA signature over hash
+func RecoverSigner(hash common.Hash, sig []byte) (common.Address, error) {
+	normalized, err := NormalizeSignature(sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	pub, err := crypto.SigToPub(hash.Bytes(), normalized)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover signer: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
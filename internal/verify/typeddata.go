@@ -0,0 +1,77 @@
+package verify
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+const eip712DomainType = "EIP712Domain"
+
+// TypedDataHashes holds the intermediate EIP-712 hashes of a payload
+type TypedDataHashes struct {
+	EncodedType     string
+	DomainSeparator common.Hash
+	StructHash      common.Hash
+	Digest          common.Hash
+}
+
+// HashTypedData computes the domain separator, the primary type's struct
+// hash and the final digest keccak256("\x19\x01" ++ domainSeparator ++ structHash).
+// If the payload omits the EIP712Domain type it is derived from the domain
+// fields that are set, as wallets and ethers.js do.
+func HashTypedData(data *apitypes.TypedData) (*TypedDataHashes, error) {
+	if data.PrimaryType == "" {
+		return nil, fmt.Errorf("primaryType is required")
+	}
+	if _, ok := data.Types[data.PrimaryType]; !ok {
+		return nil, fmt.Errorf("primaryType %s is not defined in types", data.PrimaryType)
+	}
+	if _, ok := data.Types[eip712DomainType]; !ok {
+		if data.Types == nil {
+			data.Types = apitypes.Types{}
+		}
+		data.Types[eip712DomainType] = domainType(data.Domain)
+	}
+
+	domainSeparator, err := data.HashStruct(eip712DomainType, data.Domain.Map())
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash domain: %w", err)
+	}
+	structHash, err := data.HashStruct(data.PrimaryType, data.Message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", data.PrimaryType, err)
+	}
+
+	digest := crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator, structHash)
+	return &TypedDataHashes{
+		EncodedType:     string(data.EncodeType(data.PrimaryType)),
+		DomainSeparator: common.BytesToHash(domainSeparator),
+		StructHash:      common.BytesToHash(structHash),
+		Digest:          digest,
+	}, nil
+}
+
+// domainType lists the EIP712Domain fields present in the domain, in the
+// order defined by EIP-712
+func domainType(domain apitypes.TypedDataDomain) []apitypes.Type {
+	var fields []apitypes.Type
+	if domain.Name != "" {
+		fields = append(fields, apitypes.Type{Name: "name", Type: "string"})
+	}
+	if domain.Version != "" {
+		fields = append(fields, apitypes.Type{Name: "version", Type: "string"})
+	}
+	if domain.ChainId != nil {
+		fields = append(fields, apitypes.Type{Name: "chainId", Type: "uint256"})
+	}
+	if domain.VerifyingContract != "" {
+		fields = append(fields, apitypes.Type{Name: "verifyingContract", Type: "address"})
+	}
+	if domain.Salt != "" {
+		fields = append(fields, apitypes.Type{Name: "salt", Type: "bytes32"})
+	}
+	return fields
+}
@@ -0,0 +1,191 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/multicall"
+	"go.uber.org/zap"
+)
+
+// Verification methods reported in Result
+const (
+	MethodECRecover = "ecrecover"
+	MethodEIP1271   = "eip1271"
+	MethodEIP6492   = "eip6492"
+)
+
+const eip1271ABI = `[{"inputs":[{"name":"hash","type":"bytes32"},{"name":"signature","type":"bytes"}],"name":"isValidSignature","outputs":[{"name":"","type":"bytes4"}],"stateMutability":"view","type":"function"}]`
+
+var (
+	parsedEIP1271 = mustParseABI(eip1271ABI)
+
+	// eip1271MagicValue is bytes4(keccak256("isValidSignature(bytes32,bytes)"))
+	eip1271MagicValue = []byte{0x16, 0x26, 0xba, 0x7e}
+
+	// eip6492Suffix marks a signature wrapped for a not-yet-deployed contract
+	eip6492Suffix = common.FromHex("0x6492649264926492649264926492649264926492649264926492649264926492")
+
+	// eip6492Wrapper is abi.encode(address factory, bytes factoryCalldata, bytes signature)
+	eip6492Wrapper = abi.Arguments{
+		{Type: mustNewType("address")},
+		{Type: mustNewType("bytes")},
+		{Type: mustNewType("bytes")},
+	}
+)
+
+// Backend provides the chain access needed for contract signatures.
+// chain.Validator satisfies it.
+type Backend interface {
+	multicall.Caller
+	IsContract(ctx context.Context, address string) (bool, error)
+}
+
+// Result is the outcome of a signature verification
+type Result struct {
+	Valid     bool
+	Method    string
+	Recovered string
+}
+
+// Verifier checks signatures from EOAs and smart contract wallets
+type Verifier struct {
+	backend Backend
+}
+
+// NewVerifier creates a verifier backed by the given chain
+func NewVerifier(backend Backend) *Verifier {
+	return &Verifier{backend: backend}
+}
+
+// Verify checks that signature over hash was produced by address. EOAs are
+// checked with ecrecover; deployed contracts fall back to EIP-1271
+// isValidSignature; EIP-6492 wrapped signatures of counterfactual wallets are
+// checked by deploying the wallet and calling isValidSignature in the same
+// simulated Multicall3 call.
+func (v *Verifier) Verify(ctx context.Context, address common.Address, hash common.Hash, signature []byte) (*Result, error) {
+	if bytes.HasSuffix(signature, eip6492Suffix) {
+		return v.verifyEIP6492(ctx, address, hash, signature)
+	}
+
+	result := &Result{Method: MethodECRecover}
+	var recoverErr error
+	if len(signature) == 64 || len(signature) == 65 {
+		recovered, err := RecoverSigner(hash, signature)
+		if err != nil {
+			// Contracts may still accept it through EIP-1271
+			recoverErr = err
+		} else {
+			result.Recovered = recovered.Hex()
+			if recovered == address {
+				result.Valid = true
+				return result, nil
+			}
+		}
+	}
+
+	isContract, err := v.backend.IsContract(ctx, address.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for contract signer: %w", err)
+	}
+	if !isContract {
+		if recoverErr != nil {
+			return nil, recoverErr
+		}
+		if result.Recovered == "" {
+			return nil, fmt.Errorf("invalid signature: expected 64 or 65 bytes, got %d", len(signature))
+		}
+		return result, nil
+	}
+
+	valid, err := v.isValidSignature(ctx, address, hash, signature)
+	if err != nil {
+		return nil, err
+	}
+	result.Method = MethodEIP1271
+	result.Valid = valid
+	return result, nil
+}
+
+func (v *Verifier) isValidSignature(ctx context.Context, address common.Address, hash common.Hash, signature []byte) (bool, error) {
+	data, err := parsedEIP1271.Pack("isValidSignature", hash, signature)
+	if err != nil {
+		return false, fmt.Errorf("failed to pack isValidSignature call: %w", err)
+	}
+
+	output, err := v.backend.CallContract(ctx, address.Hex(), data)
+	if err != nil {
+		// A revert means the wallet rejected the signature
+		logger.Debug("isValidSignature call failed",
+			zap.String("address", address.Hex()),
+			zap.Error(err))
+		return false, nil
+	}
+	return isMagicValue(output), nil
+}
+
+func (v *Verifier) verifyEIP6492(ctx context.Context, address common.Address, hash common.Hash, signature []byte) (*Result, error) {
+	values, err := eip6492Wrapper.Unpack(signature[:len(signature)-len(eip6492Suffix)])
+	if err != nil {
+		return nil, fmt.Errorf("invalid EIP-6492 signature wrapper: %w", err)
+	}
+	factory := values[0].(common.Address)
+	factoryCalldata := values[1].([]byte)
+	inner := values[2].([]byte)
+
+	result := &Result{Method: MethodEIP6492}
+
+	isContract, err := v.backend.IsContract(ctx, address.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for contract signer: %w", err)
+	}
+	if isContract {
+		// Already deployed: the wrapper is ignored per EIP-6492
+		result.Valid, err = v.isValidSignature(ctx, address, hash, inner)
+		return result, err
+	}
+
+	checkData, err := parsedEIP1271.Pack("isValidSignature", hash, inner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack isValidSignature call: %w", err)
+	}
+
+	results, err := multicall.Aggregate3(ctx, v.backend, []multicall.Call{
+		{Target: factory.Hex(), AllowFailure: true, CallData: factoryCalldata},
+		{Target: address.Hex(), AllowFailure: true, CallData: checkData},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to simulate counterfactual wallet: %w", err)
+	}
+	if !results[0].Success {
+		return nil, fmt.Errorf("counterfactual wallet deployment through factory %s reverted", factory.Hex())
+	}
+
+	result.Valid = results[1].Success && isMagicValue(results[1].ReturnData)
+	return result, nil
+}
+
+func isMagicValue(output []byte) bool {
+	return len(output) >= 4 && bytes.Equal(output[:4], eip1271MagicValue)
+}
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func mustNewType(t string) abi.Type {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return typ
+}
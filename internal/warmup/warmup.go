@@ -0,0 +1,297 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+	"go.uber.org/zap"
+)
+
+// Job statuses
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+// maxErrors is how many failed entries a job reports
+const maxErrors = 100
+
+var (
+	// ErrNotFound is returned for jobs that do not exist or were dropped
+	ErrNotFound = errors.New("warm-up job not found")
+
+	// ErrTooManyEntries is returned for lists longer than Config.MaxEntries
+	ErrTooManyEntries = errors.New("too many entries")
+)
+
+// Config bounds warm-up jobs
+type Config struct {
+	// Concurrency is the number of lookups a job runs at once
+	Concurrency int
+	// MaxEntries is the longest list a job accepts
+	MaxEntries int
+	// MaxJobs is how many jobs are kept; the oldest finished jobs are dropped
+	MaxJobs int
+	// Timeout bounds the lookups of a single entry
+	Timeout time.Duration
+}
+
+// EntryError is an entry that could not be looked up
+type EntryError struct {
+	Entry string `json:"entry"`
+	Error string `json:"error"`
+}
+
+// Job warms the cache with the lookups for a list of ENS names and addresses
+type Job struct {
+	ID         string       `json:"id"`
+	Chain      string       `json:"chain"`
+	Status     string       `json:"status"`
+	Total      int          `json:"total"`
+	Processed  int          `json:"processed"`
+	Failed     int          `json:"failed"`
+	Errors     []EntryError `json:"errors,omitempty"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+func (j *Job) copy() *Job {
+	c := *j
+	c.Errors = append([]EntryError(nil), j.Errors...)
+	return &c
+}
+
+// Service runs warm-up jobs in the background and keeps their progress
+type Service struct {
+	cfg      Config
+	registry *chain.Registry
+
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	order []string // job IDs, oldest first
+}
+
+func NewService(registry *chain.Registry, cfg Config) *Service {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 1
+	}
+	return &Service{
+		cfg:      cfg,
+		registry: registry,
+		jobs:     make(map[string]*Job),
+	}
+}
+
+// Start validates the chain and entries and starts a job. Each entry is an
+// ENS name, which is resolved together with the contract check of its
+// address, or an address, whose primary name and contract check are looked
+// up. Blank entries, duplicates and lines starting with # are skipped.
+func (s *Service) Start(chainName string, entries []string) (*Job, error) {
+	v, err := s.registry.Get(chainName)
+	if err != nil {
+		return nil, err
+	}
+	entries = normalize(entries)
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no entries to warm")
+	}
+	if s.cfg.MaxEntries > 0 && len(entries) > s.cfg.MaxEntries {
+		return nil, fmt.Errorf("%w: %d entries, at most %d are allowed", ErrTooManyEntries, len(entries), s.cfg.MaxEntries)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:        uuid.New().String(),
+		Chain:     v.GetChainName(),
+		Status:    StatusRunning,
+		Total:     len(entries),
+		CreatedAt: time.Now().UTC(),
+		cancel:    cancel,
+	}
+
+	s.mu.Lock()
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	s.trim()
+	snapshot := job.copy()
+	s.mu.Unlock()
+
+	logger.Info("Starting cache warm-up",
+		zap.String("job", job.ID),
+		zap.String("chain", job.Chain),
+		zap.Int("entries", job.Total))
+	go s.run(ctx, job, v, entries)
+	return snapshot, nil
+}
+
+// Job returns a job with its progress
+func (s *Service) Job(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return job.copy(), nil
+}
+
+// Jobs returns every kept job, newest first
+func (s *Service) Jobs() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]*Job, 0, len(s.order))
+	for i := len(s.order) - 1; i >= 0; i-- {
+		jobs = append(jobs, s.jobs[s.order[i]].copy())
+	}
+	return jobs
+}
+
+// Cancel stops a running job. Lookups already in flight complete.
+func (s *Service) Cancel(id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if job.Status == StatusRunning {
+		job.cancel()
+		s.finish(job, StatusCancelled)
+	}
+	return job.copy(), nil
+}
+
+func (s *Service) run(ctx context.Context, job *Job, v chain.Validator, entries []string) {
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < s.cfg.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range work {
+				err := s.warm(ctx, v, entry)
+				if err != nil && ctx.Err() != nil {
+					// Cancelled; the entry was not really tried
+					continue
+				}
+				s.record(job, entry, err)
+			}
+		}()
+	}
+
+feed:
+	for _, entry := range entries {
+		select {
+		case <-ctx.Done():
+			break feed
+		case work <- entry:
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if job.Status == StatusRunning {
+		job.cancel()
+		s.finish(job, StatusCompleted)
+	}
+	logger.Info("Cache warm-up finished",
+		zap.String("job", job.ID),
+		zap.String("status", job.Status),
+		zap.Int("processed", job.Processed),
+		zap.Int("failed", job.Failed))
+}
+
+// warm runs the lookups for one entry, which caches their results
+func (s *Service) warm(ctx context.Context, v chain.Validator, entry string) error {
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	address := entry
+	if v.IsValidAddress(entry) {
+		if _, err := v.ReverseResolveENS(ctx, entry); err != nil {
+			return err
+		}
+	} else {
+		resolved, err := v.ResolveENS(ctx, entry)
+		if err != nil {
+			return err
+		}
+		address = resolved
+	}
+	_, err := v.IsContract(ctx, address)
+	return err
+}
+
+func (s *Service) record(job *Job, entry string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job.Processed++
+	if err != nil {
+		job.Failed++
+		if len(job.Errors) < maxErrors {
+			job.Errors = append(job.Errors, EntryError{Entry: entry, Error: err.Error()})
+		}
+	}
+}
+
+func (s *Service) finish(job *Job, status string) {
+	now := time.Now().UTC()
+	job.Status = status
+	job.FinishedAt = &now
+}
+
+// trim drops the oldest finished jobs beyond MaxJobs. Running jobs are kept.
+func (s *Service) trim() {
+	excess := len(s.order) - s.cfg.MaxJobs
+	if s.cfg.MaxJobs <= 0 || excess <= 0 {
+		return
+	}
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if excess > 0 && s.jobs[id].Status != StatusRunning {
+			delete(s.jobs, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
+
+// normalize trims entries and drops blanks, comments and duplicates
+func normalize(entries []string) []string {
+	seen := make(map[string]bool, len(entries))
+	normalized := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		key := strings.ToLower(entry)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		normalized = append(normalized, entry)
+	}
+	return normalized
+}
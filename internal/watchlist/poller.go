@@ -0,0 +1,161 @@
+package watchlist
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/bytecode"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/proxy"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/screening"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+	"go.uber.org/zap"
+)
+
+// checkTimeout bounds the RPC calls of a single item check
+const checkTimeout = 30 * time.Second
+
+// Poller periodically checks every watched item for changes
+type Poller struct {
+	list          *Watchlist
+	registry      *chain.Registry
+	screener      *screening.Service
+	interval      time.Duration
+	expiryWarning time.Duration
+	hooks         []func(Event)
+}
+
+// NewPoller creates a poller checking list every interval and screening
+// watched addresses with screener. ENS names are reported as expiring
+// expiryWarning before they expire.
+func NewPoller(list *Watchlist, registry *chain.Registry, screener *screening.Service, interval, expiryWarning time.Duration) *Poller {
+	return &Poller{
+		list:          list,
+		registry:      registry,
+		screener:      screener,
+		interval:      interval,
+		expiryWarning: expiryWarning,
+	}
+}
+
+// OnEvent registers fn to be called for every detected event. Hooks must
+// be registered before Run and are called sequentially.
+func (p *Poller) OnEvent(fn func(Event)) {
+	p.hooks = append(p.hooks, fn)
+}
+
+// Run checks all items every interval until ctx is cancelled
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, item := range p.list.all() {
+				if ctx.Err() != nil {
+					return
+				}
+				if _, _, err := p.Check(ctx, item.ID); err != nil && err != ErrNotFound {
+					logger.Error("Watchlist check failed",
+						zap.String("id", item.ID),
+						zap.Error(err))
+				}
+			}
+		}
+	}
+}
+
+// Check inspects one item now and returns its updated state and the events
+// detected. A failed lookup is recorded in the item's LastError.
+func (p *Poller) Check(ctx context.Context, id string) (*Item, []Event, error) {
+	item, ok := p.list.get(id)
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	snapshot, checkErr := p.snapshot(ctx, item)
+	if checkErr != nil {
+		logger.Warn("Watchlist lookup failed",
+			zap.String("chain", item.Chain),
+			zap.String("target", item.Target),
+			zap.Error(checkErr))
+	}
+
+	updated, events, err := p.list.record(id, snapshot, checkErr, p.expiryWarning)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, e := range events {
+		logger.Info("Watchlist change detected",
+			zap.String("target", e.Target),
+			zap.String("type", e.Type),
+			zap.String("before", e.Before),
+			zap.String("after", e.After))
+		for _, hook := range p.hooks {
+			hook(e)
+		}
+	}
+	return updated, events, nil
+}
+
+func (p *Poller) snapshot(ctx context.Context, item *Item) (*Snapshot, error) {
+	v, err := p.registry.Get(item.Chain)
+	if err != nil {
+		return nil, err
+	}
+
+	if item.Kind == KindENS {
+		record, err := v.LookupENS(ctx, item.Target)
+		if err != nil {
+			return nil, err
+		}
+		snapshot := &Snapshot{
+			Address: record.Address,
+			Owner:   record.Owner,
+		}
+		if !record.Expiry.IsZero() {
+			expiry := record.Expiry
+			snapshot.Expiry = &expiry
+		}
+		snapshot.Screening = p.screen(item.Chain, item.Target, record.Address)
+		return snapshot, nil
+	}
+
+	code, err := v.GetCode(ctx, item.Target)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &Snapshot{Screening: p.screen(item.Chain, item.Target)}
+	if len(code) == 0 {
+		return snapshot, nil
+	}
+	snapshot.CodeHash = crypto.Keccak256Hash(code).Hex()
+	if target, ok := bytecode.DelegationTarget(code); ok {
+		snapshot.Delegation = target.Hex()
+		return snapshot, nil
+	}
+
+	info, err := proxy.DetectCode(ctx, v, item.Target, code)
+	if err != nil {
+		return nil, err
+	}
+	if info != nil {
+		snapshot.ProxyKind = info.Kind
+		snapshot.Implementation = info.Implementation
+	}
+	return snapshot, nil
+}
+
+func (p *Poller) screen(chainName string, subjects ...string) string {
+	if p.screener == nil {
+		return ""
+	}
+	return p.screener.Screen(chainName, subjects...).Status
+}
@@ -0,0 +1,366 @@
+package watchlist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Item kinds
+const (
+	KindAddress = "address"
+	KindENS     = "ens"
+)
+
+// Event types
+const (
+	EventCodeDeployed          = "code-deployed"
+	EventCodeChanged           = "code-changed"
+	EventDelegationChanged     = "delegation-changed"
+	EventImplementationChanged = "implementation-upgraded"
+	EventENSAddressChanged     = "ens-address-changed"
+	EventENSOwnerChanged       = "ens-owner-changed"
+	EventENSExpiring           = "ens-expiring"
+	EventScreeningChanged      = "screening-changed"
+)
+
+// EventTypes lists every event type
+var EventTypes = []string{
+	EventCodeDeployed,
+	EventCodeChanged,
+	EventDelegationChanged,
+	EventImplementationChanged,
+	EventENSAddressChanged,
+	EventENSOwnerChanged,
+	EventENSExpiring,
+	EventScreeningChanged,
+}
+
+var (
+	// ErrNotFound is returned for items that do not exist or belong to
+	// another API key
+	ErrNotFound = errors.New("watchlist item not found")
+
+	// ErrLimitReached is returned when an API key already watches the
+	// maximum number of items
+	ErrLimitReached = errors.New("watchlist limit reached")
+)
+
+// Snapshot is the watched state of an item at one point in time. Address
+// items use the code fields, ENS items the name fields; empty values mean
+// unset.
+type Snapshot struct {
+	CodeHash       string     `json:"codeHash,omitempty"`
+	Delegation     string     `json:"delegation,omitempty"`
+	ProxyKind      string     `json:"proxyKind,omitempty"`
+	Implementation string     `json:"implementation,omitempty"`
+	Address        string     `json:"address,omitempty"`
+	Owner          string     `json:"owner,omitempty"`
+	Expiry         *time.Time `json:"expiry,omitempty"`
+	// Screening is the screening status of the address, or of the name and
+	// the address it resolves to
+	Screening string `json:"screening,omitempty"`
+}
+
+// Item is an address or ENS name watched by an API key
+type Item struct {
+	ID        string     `json:"id"`
+	Owner     string     `json:"-"`
+	Chain     string     `json:"chain"`
+	Kind      string     `json:"kind"`
+	Target    string     `json:"target"`
+	CreatedAt time.Time  `json:"createdAt"`
+	CheckedAt *time.Time `json:"checkedAt,omitempty"`
+	Snapshot  *Snapshot  `json:"snapshot,omitempty"`
+	// LastError is the error of the most recent check, if it failed
+	LastError string `json:"lastError,omitempty"`
+
+	// warnedExpiry is the expiry an ens-expiring event was last raised for
+	warnedExpiry time.Time
+}
+
+// Event is a change detected on a watched item
+type Event struct {
+	ID         string    `json:"id"`
+	ItemID     string    `json:"itemId"`
+	Owner      string    `json:"-"`
+	Chain      string    `json:"chain"`
+	Kind       string    `json:"kind"`
+	Target     string    `json:"target"`
+	Type       string    `json:"type"`
+	Before     string    `json:"before"`
+	After      string    `json:"after"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// Watchlist keeps watched items and their recent events in memory, scoped
+// per API key
+type Watchlist struct {
+	mu        sync.RWMutex
+	maxItems  int
+	maxEvents int
+	items     map[string]*Item
+	events    map[string][]Event // owner -> events, oldest first
+}
+
+// New creates a watchlist allowing maxItems items and keeping the latest
+// maxEvents events per API key
+func New(maxItems, maxEvents int) *Watchlist {
+	return &Watchlist{
+		maxItems:  maxItems,
+		maxEvents: maxEvents,
+		items:     make(map[string]*Item),
+		events:    make(map[string][]Event),
+	}
+}
+
+// Add starts watching target for owner. Watching the same target twice
+// returns the existing item with created set to false.
+func (w *Watchlist) Add(owner, chain, kind, target string) (item *Item, created bool, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	count := 0
+	for _, existing := range w.items {
+		if existing.Owner != owner {
+			continue
+		}
+		if existing.Chain == chain && existing.Kind == kind && strings.EqualFold(existing.Target, target) {
+			copied := *existing
+			return &copied, false, nil
+		}
+		count++
+	}
+	if count >= w.maxItems {
+		return nil, false, ErrLimitReached
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, false, err
+	}
+	added := &Item{
+		ID:        id,
+		Owner:     owner,
+		Chain:     chain,
+		Kind:      kind,
+		Target:    target,
+		CreatedAt: time.Now().UTC(),
+	}
+	w.items[id] = added
+	copied := *added
+	return &copied, true, nil
+}
+
+// Get returns one of owner's items
+func (w *Watchlist) Get(owner, id string) (*Item, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	item, ok := w.items[id]
+	if !ok || item.Owner != owner {
+		return nil, ErrNotFound
+	}
+	copied := *item
+	return &copied, nil
+}
+
+// List returns owner's items, oldest first
+func (w *Watchlist) List(owner string) []*Item {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	list := []*Item{}
+	for _, item := range w.items {
+		if item.Owner == owner {
+			copied := *item
+			list = append(list, &copied)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// Remove stops watching one of owner's items. Its past events are kept.
+func (w *Watchlist) Remove(owner, id string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	item, ok := w.items[id]
+	if !ok || item.Owner != owner {
+		return ErrNotFound
+	}
+	delete(w.items, id)
+	return nil
+}
+
+// Events returns owner's events detected after since, newest first. An
+// empty itemID returns events of every item; limit <= 0 means no limit.
+func (w *Watchlist) Events(owner, itemID string, since time.Time, limit int) []Event {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	events := []Event{}
+	list := w.events[owner]
+	for i := len(list) - 1; i >= 0; i-- {
+		e := list[i]
+		if !e.DetectedAt.After(since) {
+			break
+		}
+		if itemID != "" && e.ItemID != itemID {
+			continue
+		}
+		events = append(events, e)
+		if limit > 0 && len(events) == limit {
+			break
+		}
+	}
+	return events
+}
+
+// get returns a copy of an item regardless of its owner
+func (w *Watchlist) get(id string) (*Item, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	item, ok := w.items[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *item
+	return &copied, true
+}
+
+// all returns a copy of every item, for polling
+func (w *Watchlist) all() []*Item {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	list := make([]*Item, 0, len(w.items))
+	for _, item := range w.items {
+		copied := *item
+		list = append(list, &copied)
+	}
+	return list
+}
+
+// record stores the outcome of checking an item and returns the events it
+// produced. A failed check keeps the previous snapshot.
+func (w *Watchlist) record(id string, next *Snapshot, checkErr error, expiryWarning time.Duration) (*Item, []Event, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	item, ok := w.items[id]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+
+	now := time.Now().UTC()
+	item.CheckedAt = &now
+	if checkErr != nil {
+		item.LastError = checkErr.Error()
+		copied := *item
+		return &copied, nil, nil
+	}
+	item.LastError = ""
+
+	var events []Event
+	for _, c := range diff(item.Snapshot, next) {
+		event, err := newEvent(item, c.kind, c.before, c.after, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, event)
+	}
+	if next.Expiry != nil && !next.Expiry.Equal(item.warnedExpiry) && now.Add(expiryWarning).After(*next.Expiry) {
+		event, err := newEvent(item, EventENSExpiring, "", next.Expiry.Format(time.RFC3339), now)
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, event)
+		item.warnedExpiry = *next.Expiry
+	}
+	item.Snapshot = next
+
+	if len(events) > 0 {
+		list := append(w.events[item.Owner], events...)
+		if len(list) > w.maxEvents {
+			list = list[len(list)-w.maxEvents:]
+		}
+		w.events[item.Owner] = list
+	}
+	copied := *item
+	return &copied, events, nil
+}
+
+type change struct {
+	kind   string
+	before string
+	after  string
+}
+
+// diff lists the changes between two snapshots. The first snapshot of an
+// item is a baseline and produces no changes.
+func diff(prev, next *Snapshot) []change {
+	if prev == nil {
+		return nil
+	}
+
+	var changes []change
+	switch {
+	case prev.Delegation != next.Delegation:
+		// Delegation designators are code too; report them only once
+		changes = append(changes, change{EventDelegationChanged, prev.Delegation, next.Delegation})
+	case prev.CodeHash == "" && next.CodeHash != "":
+		changes = append(changes, change{EventCodeDeployed, "", next.CodeHash})
+	case prev.CodeHash != next.CodeHash:
+		changes = append(changes, change{EventCodeChanged, prev.CodeHash, next.CodeHash})
+	}
+	if prev.Implementation != next.Implementation {
+		changes = append(changes, change{EventImplementationChanged, prev.Implementation, next.Implementation})
+	}
+	if prev.Address != next.Address {
+		changes = append(changes, change{EventENSAddressChanged, prev.Address, next.Address})
+	}
+	if prev.Owner != next.Owner {
+		changes = append(changes, change{EventENSOwnerChanged, prev.Owner, next.Owner})
+	}
+	if prev.Screening != next.Screening {
+		changes = append(changes, change{EventScreeningChanged, prev.Screening, next.Screening})
+	}
+	return changes
+}
+
+func newEvent(item *Item, kind, before, after string, now time.Time) (Event, error) {
+	id, err := newID()
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         id,
+		ItemID:     item.ID,
+		Owner:      item.Owner,
+		Chain:      item.Chain,
+		Kind:       item.Kind,
+		Target:     item.Target,
+		Type:       kind,
+		Before:     before,
+		After:      after,
+		DetectedAt: now,
+	}, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
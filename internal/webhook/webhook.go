@@ -0,0 +1,588 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Delivery statuses
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	// StatusDead marks deliveries in the dead-letter queue: every attempt
+	// failed and they are only sent again when replayed
+	StatusDead = "dead"
+)
+
+const (
+	// maxConcurrentDeliveries bounds the requests in flight at once
+	maxConcurrentDeliveries = 8
+	// maxResponseExcerpt is how much of a response body an attempt keeps
+	maxResponseExcerpt = 256
+	// pollInterval is how often the worker looks for due retries
+	pollInterval = time.Second
+)
+
+var (
+	// ErrNotFound is returned for endpoints and deliveries that do not exist
+	// or belong to another API key
+	ErrNotFound = errors.New("webhook not found")
+
+	// ErrLimitReached is returned when an API key already has the maximum
+	// number of endpoints
+	ErrLimitReached = errors.New("webhook endpoint limit reached")
+
+	errPrivateTarget = errors.New("webhook target resolves to a private address")
+)
+
+// Config configures delivery
+type Config struct {
+	// MaxAttempts is the number of attempts before a delivery is dead-lettered
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry; it doubles on every
+	// further retry up to MaxBackoff
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// Timeout bounds a single delivery request
+	Timeout time.Duration
+	// MaxEndpoints and MaxDeliveries are per API key
+	MaxEndpoints  int
+	MaxDeliveries int
+	// AllowPrivate permits endpoints on loopback and private networks
+	AllowPrivate bool
+}
+
+// Endpoint is a URL an API key receives events on
+type Endpoint struct {
+	ID    string `json:"id"`
+	Owner string `json:"-"`
+	URL   string `json:"url"`
+	// Events filters the event types delivered: exact types, prefixes
+	// ending in "*", or empty for every event
+	Events []string `json:"events"`
+	// Secret signs deliveries. It is only returned when the endpoint is
+	// registered.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Attempt is one delivery request
+type Attempt struct {
+	Number     int       `json:"number"`
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Response   string    `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"durationMs"`
+}
+
+// Delivery is an event queued for one endpoint
+type Delivery struct {
+	ID            string          `json:"id"`
+	EndpointID    string          `json:"endpointId"`
+	Owner         string          `json:"-"`
+	EventID       string          `json:"eventId"`
+	EventType     string          `json:"eventType"`
+	Status        string          `json:"status"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      []Attempt       `json:"attempts"`
+	NextAttemptAt *time.Time      `json:"nextAttemptAt,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
+
+	// retries counts the failed attempts since the delivery was created or
+	// last replayed
+	retries int
+}
+
+// envelope is the JSON body of every delivery
+type envelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// Service stores endpoints per API key and delivers published events to
+// them, retrying failures with exponential backoff
+type Service struct {
+	cfg    Config
+	client *http.Client
+
+	mu         sync.Mutex
+	endpoints  map[string]*Endpoint
+	deliveries map[string]*Delivery
+	order      map[string][]string // owner -> delivery IDs, oldest first
+	inFlight   map[string]bool
+	wake       chan struct{}
+}
+
+// NewService creates a webhook service. Call Run to start delivering.
+func NewService(cfg Config) *Service {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivate {
+		dialer.Control = denyPrivate
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+
+	return &Service{
+		cfg: cfg,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   cfg.Timeout,
+			// A redirect is a failed delivery; following it could reach
+			// targets that were never registered
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		endpoints:  make(map[string]*Endpoint),
+		deliveries: make(map[string]*Delivery),
+		order:      make(map[string][]string),
+		inFlight:   make(map[string]bool),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// denyPrivate refuses connections to loopback, private and link-local
+// addresses. It runs after DNS resolution, so it also covers host names
+// resolving to internal addresses.
+func denyPrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return errPrivateTarget
+	}
+	return nil
+}
+
+// Register adds an endpoint for owner. The returned endpoint carries the
+// signing secret, which is not shown again.
+func (s *Service) Register(owner, rawURL string, events []string) (*Endpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https URL")
+	}
+
+	secret, err := newID(32)
+	if err != nil {
+		return nil, err
+	}
+	id, err := newID(8)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []string{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, e := range s.endpoints {
+		if e.Owner == owner {
+			count++
+		}
+	}
+	if count >= s.cfg.MaxEndpoints {
+		return nil, ErrLimitReached
+	}
+
+	endpoint := &Endpoint{
+		ID:        id,
+		Owner:     owner,
+		URL:       u.String(),
+		Events:    events,
+		Secret:    "whsec_" + secret,
+		CreatedAt: time.Now().UTC(),
+	}
+	s.endpoints[id] = endpoint
+	copied := *endpoint
+	return &copied, nil
+}
+
+// Endpoints returns owner's endpoints without their secrets, oldest first
+func (s *Service) Endpoints(owner string) []*Endpoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := []*Endpoint{}
+	for _, e := range s.endpoints {
+		if e.Owner == owner {
+			copied := *e
+			copied.Secret = ""
+			list = append(list, &copied)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// RemoveEndpoint deletes one of owner's endpoints. Its pending deliveries
+// are dead-lettered.
+func (s *Service) RemoveEndpoint(owner, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpoint, ok := s.endpoints[id]
+	if !ok || endpoint.Owner != owner {
+		return ErrNotFound
+	}
+	delete(s.endpoints, id)
+
+	now := time.Now().UTC()
+	for _, d := range s.deliveries {
+		if d.EndpointID == id && d.Status == StatusPending {
+			d.Status = StatusDead
+			d.NextAttemptAt = nil
+			d.UpdatedAt = now
+		}
+	}
+	return nil
+}
+
+// Publish queues an event for every endpoint of owner whose filters match
+// eventType
+func (s *Service) Publish(owner, eventType string, data interface{}) {
+	eventID, err := newID(8)
+	if err != nil {
+		logger.Error("Failed to publish webhook event", zap.Error(err))
+		return
+	}
+	now := time.Now().UTC()
+	payload, err := json.Marshal(envelope{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: now,
+		Data:      data,
+	})
+	if err != nil {
+		logger.Error("Failed to encode webhook event",
+			zap.String("type", eventType),
+			zap.Error(err))
+		return
+	}
+
+	s.mu.Lock()
+	queued := 0
+	for _, endpoint := range s.endpoints {
+		if endpoint.Owner != owner || !matches(endpoint.Events, eventType) {
+			continue
+		}
+		id, err := newID(8)
+		if err != nil {
+			logger.Error("Failed to queue webhook delivery", zap.Error(err))
+			continue
+		}
+		s.deliveries[id] = &Delivery{
+			ID:            id,
+			EndpointID:    endpoint.ID,
+			Owner:         owner,
+			EventID:       eventID,
+			EventType:     eventType,
+			Status:        StatusPending,
+			Payload:       payload,
+			Attempts:      []Attempt{},
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		s.order[owner] = append(s.order[owner], id)
+		queued++
+	}
+	if queued > 0 {
+		s.trim(owner)
+	}
+	s.mu.Unlock()
+
+	if queued > 0 {
+		s.notify()
+	}
+}
+
+// Deliveries returns owner's deliveries, newest first, optionally filtered
+// by endpoint and status
+func (s *Service) Deliveries(owner, endpointID, status string) []*Delivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := []*Delivery{}
+	ids := s.order[owner]
+	for i := len(ids) - 1; i >= 0; i-- {
+		d := s.deliveries[ids[i]]
+		if endpointID != "" && d.EndpointID != endpointID {
+			continue
+		}
+		if status != "" && d.Status != status {
+			continue
+		}
+		list = append(list, d.copy())
+	}
+	return list
+}
+
+// Delivery returns one of owner's deliveries with its attempts
+func (s *Service) Delivery(owner, id string) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[id]
+	if !ok || d.Owner != owner {
+		return nil, ErrNotFound
+	}
+	return d.copy(), nil
+}
+
+// Replay queues one of owner's finished deliveries to be sent again right
+// away, with a fresh retry budget. Its attempt log is kept.
+func (s *Service) Replay(owner, id string) (*Delivery, error) {
+	s.mu.Lock()
+	d, ok := s.deliveries[id]
+	if !ok || d.Owner != owner {
+		s.mu.Unlock()
+		return nil, ErrNotFound
+	}
+	if _, ok := s.endpoints[d.EndpointID]; !ok {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("endpoint %s no longer exists", d.EndpointID)
+	}
+	if d.Status != StatusPending {
+		now := time.Now().UTC()
+		d.Status = StatusPending
+		d.NextAttemptAt = &now
+		d.UpdatedAt = now
+		d.retries = 0
+	}
+	copied := d.copy()
+	s.mu.Unlock()
+
+	s.notify()
+	return copied, nil
+}
+
+// Run delivers due events until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	slots := make(chan struct{}, maxConcurrentDeliveries)
+
+	for {
+		for _, id := range s.due() {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func(id string) {
+				defer func() { <-slots }()
+				s.deliver(ctx, id)
+			}(id)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// due claims the pending deliveries whose next attempt is due
+func (s *Service) due() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var ids []string
+	for id, d := range s.deliveries {
+		if d.Status != StatusPending || s.inFlight[id] || d.NextAttemptAt == nil || d.NextAttemptAt.After(now) {
+			continue
+		}
+		s.inFlight[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (s *Service) deliver(ctx context.Context, id string) {
+	s.mu.Lock()
+	d, ok := s.deliveries[id]
+	var endpoint *Endpoint
+	if ok {
+		endpoint = s.endpoints[d.EndpointID]
+	}
+	if !ok || endpoint == nil || d.Status != StatusPending {
+		delete(s.inFlight, id)
+		s.mu.Unlock()
+		return
+	}
+	target, secret, payload := endpoint.URL, endpoint.Secret, d.Payload
+	eventType := d.EventType
+	s.mu.Unlock()
+
+	start := time.Now()
+	attempt := Attempt{At: start.UTC()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "blockCheck-webhooks/1.0")
+		req.Header.Set(HeaderEvent, eventType)
+		req.Header.Set(HeaderDelivery, id)
+		req.Header.Set(HeaderSignature, Sign(secret, start, payload))
+
+		var resp *http.Response
+		resp, err = s.client.Do(req)
+		if err == nil {
+			excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseExcerpt))
+			resp.Body.Close()
+			attempt.StatusCode = resp.StatusCode
+			attempt.Response = string(excerpt)
+			if resp.StatusCode < 200 || resp.StatusCode > 299 {
+				err = fmt.Errorf("endpoint responded with %s", resp.Status)
+			}
+		}
+	}
+	attempt.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	if ctx.Err() != nil {
+		// Shutting down; the attempt was interrupted, not failed
+		s.mu.Lock()
+		delete(s.inFlight, id)
+		s.mu.Unlock()
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, id)
+
+	now := time.Now().UTC()
+	attempt.Number = len(d.Attempts) + 1
+	d.Attempts = append(d.Attempts, attempt)
+	d.UpdatedAt = now
+
+	if d.Status != StatusPending {
+		// Dead-lettered while in flight because its endpoint was removed
+		return
+	}
+	if err == nil {
+		d.Status = StatusDelivered
+		d.NextAttemptAt = nil
+		return
+	}
+
+	d.retries++
+	if d.retries >= s.cfg.MaxAttempts {
+		d.Status = StatusDead
+		d.NextAttemptAt = nil
+		logger.Warn("Webhook delivery moved to dead-letter queue",
+			zap.String("delivery", id),
+			zap.String("url", target),
+			zap.Int("attempts", len(d.Attempts)),
+			zap.Error(err))
+		return
+	}
+	next := now.Add(s.backoff(d.retries))
+	d.NextAttemptAt = &next
+}
+
+// backoff returns the delay after the given number of failed attempts
+func (s *Service) backoff(failures int) time.Duration {
+	delay := s.cfg.BaseBackoff
+	for i := 1; i < failures && delay < s.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, s.cfg.MaxBackoff)
+}
+
+// trim drops owner's oldest finished deliveries beyond MaxDeliveries.
+// Pending deliveries are never dropped.
+func (s *Service) trim(owner string) {
+	ids := s.order[owner]
+	excess := len(ids) - s.cfg.MaxDeliveries
+	if excess <= 0 {
+		return
+	}
+	kept := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if excess > 0 && s.deliveries[id].Status != StatusPending {
+			delete(s.deliveries, id)
+			excess--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order[owner] = kept
+}
+
+func (s *Service) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Delivery) copy() *Delivery {
+	copied := *d
+	copied.Attempts = append([]Attempt{}, d.Attempts...)
+	return &copied
+}
+
+// MatchesEvent reports whether an event filter, an exact type or a prefix
+// ending in "*", matches eventType
+func MatchesEvent(filter, eventType string) bool {
+	if prefix, ok := strings.CutSuffix(filter, "*"); ok {
+		return strings.HasPrefix(eventType, prefix)
+	}
+	return filter == eventType
+}
+
+// matches reports whether eventType passes an endpoint's filters
+func matches(filters []string, eventType string) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		if MatchesEvent(f, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+func newID(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,273 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/watchlist"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("production")
+	os.Exit(m.Run())
+}
+
+// receiver is a webhook endpoint that answers with a configurable status
+// and records the requests it receives
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	w.WriteHeader(rc.status)
+}
+
+func (rc *receiver) setStatus(status int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.status = status
+}
+
+func newTestService(allowPrivate bool) *Service {
+	return NewService(Config{
+		MaxAttempts:   3,
+		BaseBackoff:   time.Millisecond,
+		MaxBackoff:    4 * time.Millisecond,
+		Timeout:       2 * time.Second,
+		MaxEndpoints:  5,
+		MaxDeliveries: 100,
+		AllowPrivate:  allowPrivate,
+	})
+}
+
+// deliverDue sends every due delivery synchronously
+func deliverDue(t *testing.T, s *Service) int {
+	t.Helper()
+	ids := s.due()
+	for _, id := range ids {
+		s.deliver(context.Background(), id)
+	}
+	return len(ids)
+}
+
+func publishOne(t *testing.T, s *Service, url string) (*Endpoint, *Delivery) {
+	t.Helper()
+	endpoint, err := s.Register("owner", url, []string{"ens-*"})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	s.Publish("owner", watchlist.EventENSExpiring, map[string]string{"name": "vitalik.eth"})
+	deliveries := s.Deliveries("owner", endpoint.ID, "")
+	if len(deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(deliveries))
+	}
+	return endpoint, deliveries[0]
+}
+
+func TestDeliverySignatureHeaders(t *testing.T) {
+	rc := &receiver{status: http.StatusNoContent}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	s := newTestService(true)
+	endpoint, delivery := publishOne(t, s, server.URL)
+	if n := deliverDue(t, s); n != 1 {
+		t.Fatalf("delivered %d, want 1", n)
+	}
+
+	if len(rc.requests) != 1 {
+		t.Fatalf("receiver got %d requests, want 1", len(rc.requests))
+	}
+	req, body := rc.requests[0], rc.bodies[0]
+	if got := req.Header.Get(HeaderEvent); got != watchlist.EventENSExpiring {
+		t.Errorf("%s = %q, want %s", HeaderEvent, got, watchlist.EventENSExpiring)
+	}
+	if got := req.Header.Get(HeaderDelivery); got != delivery.ID {
+		t.Errorf("%s = %q, want %q", HeaderDelivery, got, delivery.ID)
+	}
+	if err := Verify(endpoint.Secret, req.Header.Get(HeaderSignature), body, time.Minute); err != nil {
+		t.Errorf("signature does not verify: %v", err)
+	}
+	if err := Verify("whsec_other", req.Header.Get(HeaderSignature), body, time.Minute); err == nil {
+		t.Error("signature verified with the wrong secret")
+	}
+	if err := Verify(endpoint.Secret, req.Header.Get(HeaderSignature), append(body, ' '), time.Minute); err == nil {
+		t.Error("signature verified a modified payload")
+	}
+
+	var event envelope
+	if err := json.Unmarshal(body, &event); err != nil || event.Type != watchlist.EventENSExpiring || event.ID != delivery.EventID {
+		t.Errorf("unexpected payload %s (%v)", body, err)
+	}
+	if d, _ := s.Delivery("owner", delivery.ID); d.Status != StatusDelivered || len(d.Attempts) != 1 {
+		t.Errorf("status %s with %d attempts, want delivered after 1", d.Status, len(d.Attempts))
+	}
+}
+
+func TestVerifyRejectsStaleSignature(t *testing.T) {
+	payload := []byte(`{"id":"1"}`)
+	header := Sign("secret", time.Now().Add(-10*time.Minute), payload)
+	if err := Verify("secret", header, payload, 5*time.Minute); err == nil {
+		t.Error("accepted a signature older than the tolerance")
+	}
+}
+
+func TestRetryIntoDeadLetterAndReplay(t *testing.T) {
+	rc := &receiver{status: http.StatusInternalServerError}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	s := newTestService(true)
+	_, delivery := publishOne(t, s, server.URL)
+
+	var previous time.Time
+	for attempt := 1; attempt <= 3; attempt++ {
+		if n := deliverDue(t, s); n != 1 {
+			t.Fatalf("attempt %d: delivered %d, want 1", attempt, n)
+		}
+		d, _ := s.Delivery("owner", delivery.ID)
+		if attempt < 3 {
+			if d.Status != StatusPending || d.NextAttemptAt == nil {
+				t.Fatalf("attempt %d: status %s, want a pending retry", attempt, d.Status)
+			}
+			if wait := d.NextAttemptAt.Sub(d.UpdatedAt); wait != s.backoff(attempt) {
+				t.Errorf("attempt %d: retry after %v, want %v", attempt, wait, s.backoff(attempt))
+			}
+			if !d.NextAttemptAt.After(previous) {
+				t.Errorf("attempt %d: retry not scheduled later", attempt)
+			}
+			previous = *d.NextAttemptAt
+			time.Sleep(time.Until(*d.NextAttemptAt) + time.Millisecond)
+		}
+	}
+
+	d, _ := s.Delivery("owner", delivery.ID)
+	if d.Status != StatusDead || d.NextAttemptAt != nil || len(d.Attempts) != 3 {
+		t.Fatalf("status %s with %d attempts, want dead after 3", d.Status, len(d.Attempts))
+	}
+	if d.Attempts[0].StatusCode != http.StatusInternalServerError || d.Attempts[0].Error == "" {
+		t.Errorf("attempt not recorded as failed: %+v", d.Attempts[0])
+	}
+	if dead := s.Deliveries("owner", "", StatusDead); len(dead) != 1 {
+		t.Errorf("dead-letter queue holds %d deliveries, want 1", len(dead))
+	}
+	if n := deliverDue(t, s); n != 0 {
+		t.Errorf("dead delivery was sent again without a replay")
+	}
+
+	rc.setStatus(http.StatusOK)
+	if _, err := s.Replay("owner", delivery.ID); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if _, err := s.Replay("someone-else", delivery.ID); err != ErrNotFound {
+		t.Errorf("replay by another owner returned %v, want ErrNotFound", err)
+	}
+	if n := deliverDue(t, s); n != 1 {
+		t.Fatalf("replay delivered %d, want 1", n)
+	}
+	d, _ = s.Delivery("owner", delivery.ID)
+	if d.Status != StatusDelivered || len(d.Attempts) != 4 {
+		t.Errorf("status %s with %d attempts, want delivered after 4", d.Status, len(d.Attempts))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := NewService(Config{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{20, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := s.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestPrivateTargetsAreRefused(t *testing.T) {
+	rc := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(rc)
+	defer server.Close()
+
+	s := newTestService(false)
+	_, delivery := publishOne(t, s, server.URL)
+	deliverDue(t, s)
+
+	if len(rc.requests) != 0 {
+		t.Fatal("request reached a loopback endpoint")
+	}
+	d, _ := s.Delivery("owner", delivery.ID)
+	if len(d.Attempts) != 1 || !strings.Contains(d.Attempts[0].Error, errPrivateTarget.Error()) {
+		t.Errorf("attempt %+v, want a private target error", d.Attempts)
+	}
+}
+
+func TestDenyPrivate(t *testing.T) {
+	tests := []struct {
+		address string
+		denied  bool
+	}{
+		{"127.0.0.1:80", true},
+		{"[::1]:443", true},
+		{"10.1.2.3:80", true},
+		{"172.16.0.1:80", true},
+		{"192.168.1.1:80", true},
+		{"169.254.169.254:80", true},
+		{"[fe80::1]:80", true},
+		{"0.0.0.0:80", true},
+		{"8.8.8.8:443", false},
+		{"[2606:4700::1111]:443", false},
+	}
+	for _, tt := range tests {
+		err := denyPrivate("tcp", tt.address, nil)
+		if denied := err != nil; denied != tt.denied {
+			t.Errorf("denyPrivate(%s) = %v, want denied %v", tt.address, err, tt.denied)
+		}
+	}
+}
+
+func TestEventFilters(t *testing.T) {
+	tests := []struct {
+		filters   []string
+		eventType string
+		want      bool
+	}{
+		{nil, watchlist.EventENSExpiring, true},
+		{[]string{"ens-expiring"}, watchlist.EventENSExpiring, true},
+		{[]string{"ens-expiring"}, watchlist.EventENSOwnerChanged, false},
+		{[]string{"ens-*"}, watchlist.EventENSOwnerChanged, true},
+		{[]string{"ens-*"}, watchlist.EventCodeChanged, false},
+		{[]string{"*"}, watchlist.EventScreeningChanged, true},
+		{[]string{"code-*", "ens-expiring"}, watchlist.EventENSExpiring, true},
+		{[]string{"ens"}, watchlist.EventENSExpiring, false},
+	}
+	for _, tt := range tests {
+		if got := matches(tt.filters, tt.eventType); got != tt.want {
+			t.Errorf("matches(%v, %s) = %v, want %v", tt.filters, tt.eventType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/screening"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/units"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+)
+
+// maxBatchAccounts bounds the size of a batched account lookup; each account
+// costs three JSON-RPC calls
+const maxBatchAccounts = 100
+
+type BalanceResponse struct {
+	Wei       string `json:"wei"`
+	Formatted string `json:"formatted"`
+}
+
+type AccountResponse struct {
+	Address             string             `json:"address"`
+	Chain               string             `json:"chain"`
+	Block               string             `json:"block"`
+	Balance             *BalanceResponse   `json:"balance,omitempty"`
+	Nonce               uint64             `json:"nonce"`
+	HasSentTransactions bool               `json:"hasSentTransactions"`
+	CodeHash            string             `json:"codeHash,omitempty"`
+	IsContract          bool               `json:"isContract"`
+	Screening           *screening.Verdict `json:"screening,omitempty"`
+	Error               string             `json:"error,omitempty"`
+}
+
+type AccountsRequest struct {
+	Addresses []string `json:"addresses"`
+	Block     string   `json:"block,omitempty"`
+}
+
+type AccountsResponse struct {
+	Chain    string            `json:"chain"`
+	Block    string            `json:"block"`
+	Accounts []AccountResponse `json:"accounts"`
+}
+
+// AccountHandler returns the native balance, nonce and code hash of an address
+func AccountHandler(registry *chain.Registry, screener *screening.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		validator, err := validatorForRequest(registry, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		address := chi.URLParam(r, "address")
+		if address == "" {
+			http.Error(w, "Address parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		block := r.URL.Query().Get("block")
+		if block == "" {
+			block = "latest"
+		}
+
+		response := AccountResponse{
+			Address: address,
+			Chain:   validator.GetChainName(),
+			Block:   block,
+		}
+
+		state, err := validator.GetAccount(r.Context(), address, block)
+		if err != nil {
+			response.Error = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			response = newAccountResponse(validator.GetChainName(), state)
+		}
+		response.Screening = screener.Screen(validator.GetChainName(), address)
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// AccountsBatchHandler returns the state of several addresses using a single
+// JSON-RPC batch request
+func AccountsBatchHandler(registry *chain.Registry, screener *screening.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		validator, err := validatorForRequest(registry, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		var req AccountsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if len(req.Addresses) == 0 {
+			http.Error(w, "At least one address is required", http.StatusBadRequest)
+			return
+		}
+		if len(req.Addresses) > maxBatchAccounts {
+			http.Error(w, fmt.Sprintf("At most %d addresses are allowed", maxBatchAccounts), http.StatusBadRequest)
+			return
+		}
+		if req.Block == "" {
+			req.Block = "latest"
+		}
+
+		states, err := validator.GetAccounts(r.Context(), req.Addresses, req.Block)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		response := AccountsResponse{
+			Chain:    validator.GetChainName(),
+			Block:    req.Block,
+			Accounts: make([]AccountResponse, 0, len(states)),
+		}
+		for _, state := range states {
+			account := newAccountResponse(response.Chain, state)
+			account.Screening = screener.Screen(response.Chain, state.Address)
+			response.Accounts = append(response.Accounts, account)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+func newAccountResponse(chainName string, state *chain.AccountState) AccountResponse {
+	response := AccountResponse{
+		Address: state.Address,
+		Chain:   chainName,
+		Block:   state.Block,
+		Error:   state.Error,
+	}
+	if state.Error != "" {
+		return response
+	}
+
+	response.Balance = &BalanceResponse{
+		Wei:       state.Balance.String(),
+		Formatted: units.FormatUnits(state.Balance, units.EtherDecimals),
+	}
+	response.Nonce = state.Nonce
+	response.CodeHash = state.CodeHash
+	response.IsContract = state.IsContract()
+	// A contract's nonce counts the contracts it created (EIP-161 starts it
+	// at 1), not transactions
+	response.HasSentTransactions = !response.IsContract && state.Nonce > 0
+	return response
+}
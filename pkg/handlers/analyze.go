@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/bytecode"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/signatures"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+)
+
+type AnalyzeResponse struct {
+	Address    string               `json:"address"`
+	IsContract bool                 `json:"isContract"`
+	Analysis   *signatures.Analysis `json:"analysis,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// AnalyzeHandler extracts function selectors and event topics from the
+// bytecode at an address and reports the interfaces it likely implements
+func AnalyzeHandler(validator chain.Validator, sigs *signatures.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		address := chi.URLParam(r, "address")
+		if address == "" {
+			http.Error(w, "Address parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		response := AnalyzeResponse{
+			Address: address,
+		}
+
+		code, err := validator.GetCode(r.Context(), address)
+		if err != nil {
+			response.Error = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+		} else if len(code) > 0 {
+			runtime, _ := bytecode.SplitMetadata(code)
+			response.IsContract = true
+			response.Analysis = sigs.Database().Analyze(
+				bytecode.FunctionSelectors(runtime),
+				bytecode.Push32Values(runtime),
+			)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
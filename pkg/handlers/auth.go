@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/auth"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator"
 	"go.uber.org/zap"
 )
 
@@ -48,3 +51,136 @@ func GenerateTokenHandler(jwtAuth *auth.JWTAuth) http.HandlerFunc {
 		}
 	}
 }
+
+type AdminTokenRequest struct {
+	Secret string `json:"secret"`
+}
+
+// GenerateAdminTokenHandler exchanges the admin secret for a JWT token
+// granted the admin scope
+func GenerateAdminTokenHandler(jwtAuth *auth.JWTAuth, adminSecret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req AdminTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if adminSecret == "" || subtle.ConstantTimeCompare([]byte(req.Secret), []byte(adminSecret)) != 1 {
+			logger.Warn("Rejected admin token request",
+				zap.String("remoteAddr", r.RemoteAddr))
+			http.Error(w, "Invalid admin secret", http.StatusUnauthorized)
+			return
+		}
+
+		apiKey := uuid.New().String()
+		token, err := jwtAuth.GenerateAdminToken(apiKey)
+		if err != nil {
+			logger.Error("Failed to generate admin token",
+				zap.Error(err))
+			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+
+		logger.Info("Admin token generated",
+			zap.String("apiKey", apiKey))
+
+		response := TokenResponse{
+			APIKey: apiKey,
+			Token:  token,
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+type NonceResponse struct {
+	Nonce     string `json:"nonce"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+type SIWELoginRequest struct {
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+type SIWELoginResponse struct {
+	Address string `json:"address"`
+	ChainID uint64 `json:"chainId"`
+	Token   string `json:"token"`
+}
+
+// SIWENonceHandler issues a single-use nonce for Sign-In with Ethereum
+func SIWENonceHandler(siwe *auth.SIWEAuth) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		nonce, expiresAt, err := siwe.IssueNonce(r.Context())
+		if err != nil {
+			logger.Error("Failed to issue SIWE nonce",
+				zap.Error(err))
+			http.Error(w, "Failed to issue nonce", http.StatusInternalServerError)
+			return
+		}
+
+		response := NonceResponse{
+			Nonce:     nonce,
+			ExpiresAt: expiresAt.UTC().Format(time.RFC3339),
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// SIWELoginHandler verifies a signed EIP-4361 message and issues a JWT whose
+// subject is the wallet address
+func SIWELoginHandler(jwtAuth *auth.JWTAuth, siwe *auth.SIWEAuth) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req SIWELoginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		signature, err := validator.DecodeHex(req.Signature)
+		if err != nil || len(signature) == 0 {
+			http.Error(w, "Invalid signature encoding", http.StatusBadRequest)
+			return
+		}
+
+		msg, err := siwe.Verify(r.Context(), req.Message, signature)
+		if err != nil {
+			logger.Warn("SIWE verification failed",
+				zap.Error(err))
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		token, err := jwtAuth.GenerateWalletToken(msg.Address)
+		if err != nil {
+			logger.Error("Failed to generate token",
+				zap.Error(err))
+			http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+			return
+		}
+
+		logger.Info("Wallet signed in",
+			zap.String("address", msg.Address),
+			zap.Uint64("chainId", msg.ChainID))
+
+		response := SIWELoginResponse{
+			Address: msg.Address,
+			ChainID: msg.ChainID,
+			Token:   token,
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache/types"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/logger"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/warmup"
+	"go.uber.org/zap"
+)
+
+// maxWarmupBody bounds uploaded warm-up lists
+const maxWarmupBody = 8 << 20
+
+type CacheCounters struct {
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hitRatio"`
+}
+
+type CacheTierStats struct {
+	CacheCounters
+	Keys      uint64 `json:"keys"`
+	Evictions uint64 `json:"evictions"`
+	Bytes     uint64 `json:"bytes,omitempty"`
+}
+
+type CacheStatsResponse struct {
+	CacheTierStats
+	Tiers map[string]CacheTierStats `json:"tiers,omitempty"`
+	// Namespaces counts lookups by kind of entry, e.g. ens or contract
+	Namespaces map[string]CacheCounters `json:"namespaces"`
+}
+
+type CacheEntryResponse struct {
+	*cache.Entry
+	// TTLSeconds is the time left before the entry is dropped; omitted for
+	// entries that do not expire
+	TTLSeconds *float64 `json:"ttlSeconds,omitempty"`
+}
+
+type CachePurgeResponse struct {
+	Pattern string `json:"pattern"`
+	Deleted int    `json:"deleted"`
+}
+
+type CacheWarmupRequest struct {
+	Chain string `json:"chain"`
+	// Entries are ENS names and addresses
+	Entries []string `json:"entries"`
+}
+
+type CacheWarmupJobsResponse struct {
+	Jobs []*warmup.Job `json:"jobs"`
+}
+
+// CacheStatsHandler returns the backend statistics and the hit ratio of each
+// namespace
+func CacheStatsHandler(addressCache *cache.AddressCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		stats := addressCache.GetStats()
+		response := CacheStatsResponse{
+			CacheTierStats: tierStats(stats),
+			Namespaces:     make(map[string]CacheCounters),
+		}
+		if len(stats.Tiers) > 0 {
+			response.Tiers = make(map[string]CacheTierStats, len(stats.Tiers))
+			for name, tier := range stats.Tiers {
+				response.Tiers[name] = tierStats(tier)
+			}
+		}
+		for name, namespace := range addressCache.NamespaceStats() {
+			response.Namespaces[name] = counters(namespace.Hits, namespace.Misses)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// GetCacheEntryHandler returns one entry with its remaining TTL
+func GetCacheEntryHandler(addressCache *cache.AddressCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		key, err := url.PathUnescape(chi.URLParam(r, "key"))
+		if err != nil {
+			http.Error(w, "Invalid key", http.StatusBadRequest)
+			return
+		}
+		entry, err := addressCache.Inspect(r.Context(), key)
+		if err != nil {
+			writeCacheError(w, "Failed to read cache entry", err)
+			return
+		}
+		if entry == nil {
+			http.Error(w, "Entry not found", http.StatusNotFound)
+			return
+		}
+
+		response := CacheEntryResponse{Entry: entry}
+		if entry.TTL > 0 {
+			seconds := entry.TTL.Seconds()
+			response.TTLSeconds = &seconds
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// DeleteCacheEntryHandler purges one entry
+func DeleteCacheEntryHandler(addressCache *cache.AddressCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key, err := url.PathUnescape(chi.URLParam(r, "key"))
+		if err != nil {
+			http.Error(w, "Invalid key", http.StatusBadRequest)
+			return
+		}
+		if err := addressCache.Purge(r.Context(), key); err != nil {
+			writeCacheError(w, "Failed to purge cache entry", err)
+			return
+		}
+
+		logger.Info("Purged cache entry",
+			zap.String("key", key))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PurgeCacheEntriesHandler purges the entries whose keys match the glob in
+// the pattern query parameter, e.g. ens:*.uni.eth
+func PurgeCacheEntriesHandler(addressCache *cache.AddressCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		pattern := r.URL.Query().Get("pattern")
+		if pattern == "" {
+			http.Error(w, "pattern is required", http.StatusBadRequest)
+			return
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			http.Error(w, "Invalid pattern", http.StatusBadRequest)
+			return
+		}
+
+		deleted, err := addressCache.PurgeMatching(r.Context(), pattern)
+		if err != nil {
+			writeCacheError(w, "Failed to purge cache entries", err)
+			return
+		}
+
+		logger.Info("Purged cache entries",
+			zap.String("pattern", pattern),
+			zap.Int("deleted", deleted))
+		response := CachePurgeResponse{Pattern: pattern, Deleted: deleted}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// PurgeCacheHandler purges every entry
+func PurgeCacheHandler(addressCache *cache.AddressCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := addressCache.Clear(r.Context()); err != nil {
+			writeCacheError(w, "Failed to purge cache", err)
+			return
+		}
+
+		logger.Info("Purged cache")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// StartCacheWarmupHandler starts a background job looking up a list of ENS
+// names and addresses. The list is a JSON CacheWarmupRequest, or a text/plain
+// upload with one entry per line and the chain in the ?chain= query
+// parameter.
+func StartCacheWarmupHandler(warmups *warmup.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxWarmupBody)
+		var req CacheWarmupRequest
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaType == "text/plain" {
+			req.Chain = r.URL.Query().Get("chain")
+			scanner := bufio.NewScanner(r.Body)
+			for scanner.Scan() {
+				req.Entries = append(req.Entries, scanner.Text())
+			}
+			if err := scanner.Err(); err != nil {
+				http.Error(w, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Chain == "" {
+			req.Chain = defaultChain
+		}
+
+		job, err := warmups.Start(req.Chain, req.Entries)
+		if errors.Is(err, warmup.ErrTooManyEntries) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// ListCacheWarmupsHandler lists the warm-up jobs, newest first
+func ListCacheWarmupsHandler(warmups *warmup.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		response := CacheWarmupJobsResponse{Jobs: warmups.Jobs()}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// GetCacheWarmupHandler returns a warm-up job with its progress
+func GetCacheWarmupHandler(warmups *warmup.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		job, err := warmups.Job(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// CancelCacheWarmupHandler stops a running warm-up job
+func CancelCacheWarmupHandler(warmups *warmup.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		job, err := warmups.Cancel(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(job); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+func writeCacheError(w http.ResponseWriter, message string, err error) {
+	if errors.Is(err, cache.ErrAdminUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	logger.Error(message,
+		zap.Error(err))
+	http.Error(w, message, http.StatusInternalServerError)
+}
+
+func tierStats(stats types.Stats) CacheTierStats {
+	return CacheTierStats{
+		CacheCounters: counters(stats.Hits, stats.Misses),
+		Keys:          stats.Keys,
+		Evictions:     stats.Evictions,
+		Bytes:         stats.Bytes,
+	}
+}
+
+func counters(hits, misses uint64) CacheCounters {
+	c := CacheCounters{Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		c.HitRatio = float64(hits) / float64(total)
+	}
+	return c
+}
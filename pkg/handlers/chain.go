@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+)
+
+// defaultChain is used by routes that do not select a chain
+const defaultChain = "ethereum"
+
+// validatorForRequest returns the validator for the chain selected by the
+// {chain} URL parameter or the ?chain= query parameter
+func validatorForRequest(registry *chain.Registry, r *http.Request) (chain.Validator, error) {
+	name := chi.URLParam(r, "chain")
+	if name == "" {
+		name = r.URL.Query().Get("chain")
+	}
+	if name == "" {
+		name = defaultChain
+	}
+	return registry.Get(name)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+)
+
+type ComputeAddressRequest struct {
+	Scheme       string `json:"scheme"`
+	Sender       string `json:"sender,omitempty"`
+	Nonce        uint64 `json:"nonce,omitempty"`
+	Deployer     string `json:"deployer,omitempty"`
+	Salt         string `json:"salt,omitempty"`
+	InitCode     string `json:"initCode,omitempty"`
+	InitCodeHash string `json:"initCodeHash,omitempty"`
+	Variant      string `json:"variant,omitempty"`
+	ChainID      uint64 `json:"chainId,omitempty"`
+}
+
+type ComputeAddressResponse struct {
+	Scheme     string `json:"scheme"`
+	Address    string `json:"address,omitempty"`
+	Proxy      string `json:"proxy,omitempty"`
+	IsDeployed bool   `json:"isDeployed"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ComputeAddressHandler computes counterfactual CREATE, CREATE2 and CREATE3
+// deployment addresses and reports whether code is already deployed there
+func ComputeAddressHandler(v chain.Validator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req ComputeAddressRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		response := ComputeAddressResponse{
+			Scheme: req.Scheme,
+		}
+
+		address, proxy, err := computeAddress(req)
+		if err != nil {
+			response.Error = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			response.Address = address
+			response.Proxy = proxy
+
+			isContract, err := v.IsContract(r.Context(), address)
+			if err != nil {
+				response.Error = fmt.Sprintf("failed to check deployment: %v", err)
+			}
+			response.IsDeployed = isContract
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+func computeAddress(req ComputeAddressRequest) (string, string, error) {
+	switch req.Scheme {
+	case "create":
+		address, err := validator.CreateAddress(req.Sender, req.Nonce)
+		return address, "", err
+
+	case "create2":
+		salt, err := validator.DecodeHex(req.Salt)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid salt: %w", err)
+		}
+		initCodeHash, err := initCodeHashFromRequest(req)
+		if err != nil {
+			return "", "", err
+		}
+		address, err := validator.Create2Address(req.Deployer, salt, initCodeHash)
+		return address, "", err
+
+	case "create3":
+		salt, err := validator.DecodeHex(req.Salt)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid salt: %w", err)
+		}
+		variant := req.Variant
+		if variant == "" {
+			variant = validator.Create3Solady
+		}
+		var chainID *big.Int
+		if req.ChainID != 0 {
+			chainID = new(big.Int).SetUint64(req.ChainID)
+		}
+		return validator.Create3Address(variant, req.Deployer, req.Sender, salt, chainID)
+
+	default:
+		return "", "", fmt.Errorf("unsupported scheme %q: expected create, create2 or create3", req.Scheme)
+	}
+}
+
+func initCodeHashFromRequest(req ComputeAddressRequest) ([]byte, error) {
+	if req.InitCodeHash != "" {
+		hash, err := validator.DecodeHex(req.InitCodeHash)
+		if err != nil {
+			return nil, fmt.Errorf("invalid initCodeHash: %w", err)
+		}
+		return hash, nil
+	}
+	if req.InitCode != "" {
+		code, err := validator.DecodeHex(req.InitCode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid initCode: %w", err)
+		}
+		return validator.InitCodeHash(code), nil
+	}
+	return nil, fmt.Errorf("initCode or initCodeHash is required for create2")
+}
@@ -6,17 +6,19 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
 )
 
 type ContractResponse struct {
-	Address    string `json:"address"`
-	IsContract bool   `json:"isContract"`
-	Error      string `json:"error,omitempty"`
+	Address    string        `json:"address"`
+	IsContract bool          `json:"isContract"`
+	Label      *labels.Label `json:"label,omitempty"`
+	Error      string        `json:"error,omitempty"`
 }
 
 // IsContractHandler handles contract detection requests
-func IsContractHandler(validator chain.Validator) http.HandlerFunc {
+func IsContractHandler(validator chain.Validator, store labels.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
@@ -29,6 +31,7 @@ func IsContractHandler(validator chain.Validator) http.HandlerFunc {
 		isContract, err := validator.IsContract(r.Context(), address)
 		response := ContractResponse{
 			Address: address,
+			Label:   lookupLabel(r, store, validator.GetChainName(), address),
 		}
 
 		if err != nil {
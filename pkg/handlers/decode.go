@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/bytecode"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/calldata"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/proxy"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/signatures"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/txdecode"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+)
+
+// Recipient flags
+const (
+	flagContract     = "contract"
+	flagProxy        = "proxy"
+	flagUnresolvable = "unresolvable"
+)
+
+type DecodeTxRequest struct {
+	RawTx string `json:"rawTx"`
+}
+
+type RecipientResponse struct {
+	Address    string      `json:"address"`
+	IsContract bool        `json:"isContract"`
+	Proxy      *proxy.Info `json:"proxy,omitempty"`
+	Flags      []string    `json:"flags"`
+	Error      string      `json:"error,omitempty"`
+}
+
+type AuthorizationResponse struct {
+	ChainID        string `json:"chainId"`
+	ChainIDMatches bool   `json:"chainIdMatches"`
+	Address        string `json:"address"`
+	Nonce          uint64 `json:"nonce"`
+	Authority      string `json:"authority,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+type DecodeTxResponse struct {
+	Hash                 string                  `json:"hash,omitempty"`
+	Type                 uint8                   `json:"type"`
+	ChainID              string                  `json:"chainId,omitempty"`
+	ChainIDMatches       *bool                   `json:"chainIdMatches,omitempty"`
+	From                 string                  `json:"from,omitempty"`
+	To                   string                  `json:"to,omitempty"`
+	ContractAddress      string                  `json:"contractAddress,omitempty"`
+	Nonce                uint64                  `json:"nonce"`
+	Value                string                  `json:"value,omitempty"`
+	Gas                  uint64                  `json:"gas"`
+	GasPrice             string                  `json:"gasPrice,omitempty"`
+	MaxFeePerGas         string                  `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas string                  `json:"maxPriorityFeePerGas,omitempty"`
+	MaxFeePerBlobGas     string                  `json:"maxFeePerBlobGas,omitempty"`
+	BlobHashes           []string                `json:"blobHashes,omitempty"`
+	Data                 string                  `json:"data,omitempty"`
+	Selector             string                  `json:"selector,omitempty"`
+	AccessList           types.AccessList        `json:"accessList,omitempty"`
+	Authorizations       []AuthorizationResponse `json:"authorizations,omitempty"`
+	Recipient            *RecipientResponse      `json:"recipient,omitempty"`
+	Warnings             []string                `json:"warnings,omitempty"`
+	Error                string                  `json:"error,omitempty"`
+}
+
+// DecodeTxHandler decodes a raw signed transaction, recovers its sender and
+// checks it against the selected chain before it is broadcast
+func DecodeTxHandler(registry *chain.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		v, err := validatorForRequest(registry, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		var req DecodeTxRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.RawTx == "" {
+			http.Error(w, "rawTx is required", http.StatusBadRequest)
+			return
+		}
+		raw, err := validator.DecodeHex(req.RawTx)
+		if err != nil {
+			http.Error(w, "Invalid rawTx encoding", http.StatusBadRequest)
+			return
+		}
+
+		tx, err := txdecode.Decode(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(DecodeTxResponse{Error: err.Error()}); err != nil {
+				logrus.Errorf("Failed to encode response: %v", err)
+			}
+			return
+		}
+
+		chainID := v.GetChainID()
+		response := DecodeTxResponse{
+			Hash:       tx.Hash.Hex(),
+			Type:       tx.Type,
+			From:       tx.From.Hex(),
+			Nonce:      tx.Nonce,
+			Value:      tx.Value.String(),
+			Gas:        tx.Gas,
+			AccessList: tx.AccessList,
+		}
+		if len(tx.Data) > 0 {
+			response.Data = hexutil.Encode(tx.Data)
+		}
+		if len(tx.Data) >= 4 {
+			response.Selector = hexutil.Encode(tx.Data[:4])
+		}
+		for _, h := range tx.BlobHashes {
+			response.BlobHashes = append(response.BlobHashes, h.Hex())
+		}
+		response.GasPrice = bigString(tx.GasPrice)
+		response.MaxFeePerGas = bigString(tx.MaxFeePerGas)
+		response.MaxPriorityFeePerGas = bigString(tx.MaxPriorityFeePerGas)
+		response.MaxFeePerBlobGas = bigString(tx.MaxFeePerBlobGas)
+
+		if tx.ChainID != nil {
+			response.ChainID = tx.ChainID.String()
+			matches := tx.ChainID.Cmp(chainID) == 0
+			response.ChainIDMatches = &matches
+			if !matches {
+				response.Warnings = append(response.Warnings,
+					fmt.Sprintf("transaction chain ID %s does not match %s (%s)", tx.ChainID, v.GetChainName(), chainID))
+			}
+		} else {
+			response.Warnings = append(response.Warnings,
+				"transaction is not EIP-155 protected and can be replayed on any chain")
+		}
+
+		for _, auth := range tx.Authorizations {
+			item := AuthorizationResponse{
+				ChainID:        auth.ChainID.String(),
+				ChainIDMatches: auth.ChainID.Sign() == 0 || auth.ChainID.Cmp(chainID) == 0,
+				Address:        auth.Address.Hex(),
+				Nonce:          auth.Nonce,
+				Error:          auth.Error,
+			}
+			if auth.Authority != nil {
+				item.Authority = auth.Authority.Hex()
+			}
+			if auth.ChainID.Sign() == 0 {
+				response.Warnings = append(response.Warnings,
+					fmt.Sprintf("authorization delegating to %s is valid on every chain", item.Address))
+			}
+			response.Authorizations = append(response.Authorizations, item)
+		}
+
+		if tx.To == nil {
+			response.ContractAddress, _ = validator.CreateAddress(tx.From.Hex(), tx.Nonce)
+		} else {
+			response.To = tx.To.Hex()
+			response.Recipient = inspectRecipient(r, v, *tx.To)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// inspectRecipient classifies a transaction's destination. Failures are
+// reported on the recipient as "unresolvable" rather than failing the request.
+func inspectRecipient(r *http.Request, v chain.Validator, to common.Address) *RecipientResponse {
+	recipient := &RecipientResponse{
+		Address: to.Hex(),
+		Flags:   []string{},
+	}
+
+	code, err := v.GetCode(r.Context(), to.Hex())
+	if err != nil {
+		recipient.Flags = append(recipient.Flags, flagUnresolvable)
+		recipient.Error = err.Error()
+		return recipient
+	}
+	if len(code) == 0 {
+		return recipient
+	}
+	recipient.IsContract = true
+	recipient.Flags = append(recipient.Flags, flagContract)
+
+	info, err := proxy.DetectCode(r.Context(), v, to.Hex(), code)
+	if err != nil {
+		recipient.Flags = append(recipient.Flags, flagUnresolvable)
+		recipient.Error = err.Error()
+		return recipient
+	}
+	if info != nil {
+		recipient.Proxy = info
+		recipient.Flags = append(recipient.Flags, flagProxy)
+	}
+	return recipient
+}
+
+func bigString(n *big.Int) string {
+	if n == nil {
+		return ""
+	}
+	return n.String()
+}
+
+// maxCalldataAddresses bounds the on-chain lookups made for one payload
+const maxCalldataAddresses = 50
+
+// Address kinds
+const (
+	addressKindEOA          = "eoa"
+	addressKindContract     = "contract"
+	addressKindDelegatedEOA = "delegated-eoa"
+)
+
+type DecodeCalldataRequest struct {
+	Data string          `json:"data"`
+	ABI  json.RawMessage `json:"abi,omitempty"`
+	To   string          `json:"to,omitempty"`
+}
+
+type AddressCheckResponse struct {
+	Path       string   `json:"path"`
+	Address    string   `json:"address"`
+	Kind       string   `json:"kind,omitempty"`
+	IsContract bool     `json:"isContract"`
+	ENSName    string   `json:"ensName,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+type DecodeCalldataResponse struct {
+	Selector            string                 `json:"selector,omitempty"`
+	Signature           string                 `json:"signature,omitempty"`
+	Source              string                 `json:"source,omitempty"`
+	To                  string                 `json:"to,omitempty"`
+	Proxy               *proxy.Info            `json:"proxy,omitempty"`
+	SelectorImplemented *bool                  `json:"selectorImplemented,omitempty"`
+	Arguments           []calldata.Argument    `json:"arguments,omitempty"`
+	Addresses           []AddressCheckResponse `json:"addresses,omitempty"`
+	Alternatives        []string               `json:"alternatives,omitempty"`
+	Warnings            []string               `json:"warnings,omitempty"`
+	Error               string                 `json:"error,omitempty"`
+}
+
+// DecodeCalldataHandler decodes calldata against a supplied ABI, or against
+// signatures inferred for the target contract, and checks every address
+// argument it contains
+func DecodeCalldataHandler(registry *chain.Registry, sigs *signatures.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		v, err := validatorForRequest(registry, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		var req DecodeCalldataRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if len(req.ABI) == 0 && req.To == "" {
+			http.Error(w, "Either abi or to is required", http.StatusBadRequest)
+			return
+		}
+		data, err := validator.DecodeHex(req.Data)
+		if err != nil || len(data) < 4 {
+			http.Error(w, "data must be hex encoded calldata of at least 4 bytes", http.StatusBadRequest)
+			return
+		}
+
+		response := DecodeCalldataResponse{
+			Selector: hexutil.Encode(data[:4]),
+		}
+
+		var target *common.Address
+		if req.To != "" {
+			address, _, err := resolveAddressOrName(r.Context(), v, req.To)
+			if err != nil {
+				writeDecodeCalldataError(w, response, err)
+				return
+			}
+			target = &address
+			response.To = address.Hex()
+		}
+
+		var decoded *calldata.Decoded
+		if len(req.ABI) > 0 {
+			response.Source = "abi"
+			decoded, err = decodeWithABI(req.ABI, data)
+		} else {
+			response.Source = "signature-db"
+			decoded, err = decodeWithSignatures(r, v, sigs.Database(), *target, data, &response)
+		}
+		if err != nil {
+			writeDecodeCalldataError(w, response, err)
+			return
+		}
+
+		response.Signature = decoded.Method.Sig
+		response.Arguments = decoded.Arguments
+		if !decoded.Canonical {
+			response.Warnings = append(response.Warnings,
+				"calldata is not canonically encoded: it contains dirty padding or trailing bytes")
+		}
+		response.Addresses = checkAddresses(r, v, decoded.Addresses, target)
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+func writeDecodeCalldataError(w http.ResponseWriter, response DecodeCalldataResponse, err error) {
+	response.Error = err.Error()
+	w.WriteHeader(http.StatusBadRequest)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logrus.Errorf("Failed to encode response: %v", err)
+	}
+}
+
+func decodeWithABI(definition json.RawMessage, data []byte) (*calldata.Decoded, error) {
+	parsed, err := abi.JSON(bytes.NewReader(definition))
+	if err != nil {
+		return nil, fmt.Errorf("invalid abi: %w", err)
+	}
+	method, err := parsed.MethodById(data[:4])
+	if err != nil {
+		return nil, err
+	}
+	return calldata.Decode(*method, data)
+}
+
+// decodeWithSignatures tries every known signature for the selector. When
+// the target is a proxy, the implementation's dispatcher is used to check
+// that the selector is actually implemented.
+func decodeWithSignatures(r *http.Request, v chain.Validator, db *signatures.Database, target common.Address, data []byte, response *DecodeCalldataResponse) (*calldata.Decoded, error) {
+	var selector [4]byte
+	copy(selector[:], data[:4])
+
+	code, err := v.GetCode(r.Context(), target.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch code for %s: %w", target.Hex(), err)
+	}
+	if len(code) == 0 {
+		response.Warnings = append(response.Warnings,
+			fmt.Sprintf("%s has no code: calls to it do not execute", target.Hex()))
+	} else {
+		dispatcher := code
+		info, err := proxy.DetectCode(r.Context(), v, target.Hex(), code)
+		if err != nil {
+			response.Warnings = append(response.Warnings,
+				fmt.Sprintf("failed to resolve proxy implementation: %v", err))
+		} else if info != nil {
+			response.Proxy = info
+			if dispatcher, err = v.GetCode(r.Context(), info.Implementation); err != nil {
+				return nil, fmt.Errorf("failed to fetch implementation code: %w", err)
+			}
+		}
+
+		runtime, _ := bytecode.SplitMetadata(dispatcher)
+		implemented := false
+		for _, s := range bytecode.FunctionSelectors(runtime) {
+			if s == selector {
+				implemented = true
+				break
+			}
+		}
+		response.SelectorImplemented = &implemented
+	}
+
+	var decoded *calldata.Decoded
+	for _, sig := range db.Functions(selector) {
+		method, err := signatures.ParseFunction(sig)
+		if err != nil {
+			continue
+		}
+		candidate, err := calldata.Decode(method, data)
+		if err != nil {
+			continue
+		}
+		if decoded == nil {
+			decoded = candidate
+		} else {
+			response.Alternatives = append(response.Alternatives, sig)
+		}
+	}
+	if decoded == nil {
+		return nil, fmt.Errorf("no known signature decodes selector %s", hexutil.Encode(data[:4]))
+	}
+	return decoded, nil
+}
+
+// checkAddresses classifies each distinct address argument and looks up its
+// primary ENS name
+func checkAddresses(r *http.Request, v chain.Validator, refs []calldata.AddressRef, target *common.Address) []AddressCheckResponse {
+	type lookup struct {
+		kind    string
+		ensName string
+		err     error
+	}
+	seen := make(map[common.Address]*lookup)
+
+	checks := make([]AddressCheckResponse, 0, len(refs))
+	for _, ref := range refs {
+		check := AddressCheckResponse{
+			Path:    ref.Path,
+			Address: ref.Address.Hex(),
+		}
+
+		if ref.Address == (common.Address{}) {
+			check.Warnings = append(check.Warnings, "zero address")
+		}
+		if target != nil && ref.Address == *target {
+			check.Warnings = append(check.Warnings, "address is the called contract itself")
+		}
+
+		result, ok := seen[ref.Address]
+		if !ok && len(seen) >= maxCalldataAddresses {
+			check.Error = "too many distinct addresses to check"
+			checks = append(checks, check)
+			continue
+		}
+		if !ok {
+			result = &lookup{}
+			seen[ref.Address] = result
+			code, err := v.GetCode(r.Context(), ref.Address.Hex())
+			switch {
+			case err != nil:
+				result.err = err
+			case len(code) == 0:
+				result.kind = addressKindEOA
+			default:
+				result.kind = addressKindContract
+				if _, delegated := bytecode.DelegationTarget(code); delegated {
+					result.kind = addressKindDelegatedEOA
+				}
+			}
+			if result.err == nil {
+				result.ensName, result.err = v.ReverseResolveENS(r.Context(), ref.Address.Hex())
+			}
+		}
+
+		check.Kind = result.kind
+		check.IsContract = result.kind == addressKindContract
+		check.ENSName = result.ensName
+		if result.err != nil {
+			check.Error = result.err.Error()
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
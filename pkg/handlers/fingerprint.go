@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/bytecode"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+)
+
+type FingerprintResponse struct {
+	Address     string                `json:"address"`
+	IsContract  bool                  `json:"isContract"`
+	Fingerprint *bytecode.Fingerprint `json:"fingerprint,omitempty"`
+	Error       string                `json:"error,omitempty"`
+}
+
+// FingerprintHandler identifies the contract deployed at an address by its bytecode
+func FingerprintHandler(validator chain.Validator, fingerprints *bytecode.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		address := chi.URLParam(r, "address")
+		if address == "" {
+			http.Error(w, "Address parameter is required", http.StatusBadRequest)
+			return
+		}
+
+		response := FingerprintResponse{
+			Address: address,
+		}
+
+		code, err := validator.GetCode(r.Context(), address)
+		if err != nil {
+			response.Error = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+		} else if len(code) > 0 {
+			response.IsContract = true
+			response.Fingerprint = fingerprints.Fingerprint(code)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/head"
+)
+
+type ChainHeadsResponse struct {
+	Chains []head.State `json:"chains"`
+}
+
+// ChainHeadsHandler returns the latest and finalized block tracked for each
+// chain and the reorgs detected since startup
+func ChainHeadsHandler(tracker *head.Tracker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		states := tracker.States()
+		sort.Slice(states, func(i, j int) bool {
+			return states[i].Chain < states[j].Chain
+		})
+		if err := json.NewEncoder(w).Encode(ChainHeadsResponse{Chains: states}); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/auth"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+)
+
+type LabelRequest struct {
+	Label    string   `json:"label"`
+	Category string   `json:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Notes    string   `json:"notes,omitempty"`
+	Team     string   `json:"team,omitempty"`
+}
+
+type LabelsResponse struct {
+	Chain  string          `json:"chain"`
+	Labels []*labels.Label `json:"labels"`
+}
+
+// ListLabelsHandler lists the caller's labels on a chain
+func ListLabelsHandler(registry *chain.Registry, store labels.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		v, err := validatorForRequest(registry, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		list, err := store.List(r.Context(), auth.APIKeyFromContext(r.Context()), v.GetChainName())
+		if err != nil {
+			logrus.Errorf("Failed to list labels: %v", err)
+			http.Error(w, "Failed to list labels", http.StatusInternalServerError)
+			return
+		}
+		if list == nil {
+			list = []*labels.Label{}
+		}
+
+		response := LabelsResponse{
+			Chain:  v.GetChainName(),
+			Labels: list,
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// GetLabelHandler returns the caller's label for an address
+func GetLabelHandler(registry *chain.Registry, store labels.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		v, address, ok := labelTarget(w, r, registry)
+		if !ok {
+			return
+		}
+
+		label, err := store.Get(r.Context(), auth.APIKeyFromContext(r.Context()), v.GetChainName(), address)
+		if err != nil {
+			logrus.Errorf("Failed to get label: %v", err)
+			http.Error(w, "Failed to get label", http.StatusInternalServerError)
+			return
+		}
+		if label == nil {
+			http.Error(w, labels.ErrNotFound.Error(), http.StatusNotFound)
+			return
+		}
+
+		if err := json.NewEncoder(w).Encode(label); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// PutLabelHandler creates or replaces the caller's label for an address
+func PutLabelHandler(registry *chain.Registry, store labels.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		v, address, ok := labelTarget(w, r, registry)
+		if !ok {
+			return
+		}
+
+		var req LabelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+
+		owner := auth.APIKeyFromContext(r.Context())
+		existing, err := store.Get(r.Context(), owner, v.GetChainName(), address)
+		if err != nil {
+			logrus.Errorf("Failed to get label: %v", err)
+			http.Error(w, "Failed to save label", http.StatusInternalServerError)
+			return
+		}
+
+		now := time.Now().UTC()
+		label := &labels.Label{
+			Chain:     v.GetChainName(),
+			Address:   address,
+			Label:     req.Label,
+			Category:  req.Category,
+			Tags:      req.Tags,
+			Notes:     req.Notes,
+			Team:      req.Team,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if existing != nil {
+			label.CreatedAt = existing.CreatedAt
+		}
+		if err := label.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := store.Put(r.Context(), owner, label); err != nil {
+			logrus.Errorf("Failed to save label: %v", err)
+			http.Error(w, "Failed to save label", http.StatusInternalServerError)
+			return
+		}
+
+		if existing == nil {
+			w.WriteHeader(http.StatusCreated)
+		}
+		if err := json.NewEncoder(w).Encode(label); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// DeleteLabelHandler removes the caller's label for an address
+func DeleteLabelHandler(registry *chain.Registry, store labels.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v, address, ok := labelTarget(w, r, registry)
+		if !ok {
+			return
+		}
+
+		err := store.Delete(r.Context(), auth.APIKeyFromContext(r.Context()), v.GetChainName(), address)
+		if errors.Is(err, labels.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Failed to delete label: %v", err)
+			http.Error(w, "Failed to delete label", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// labelTarget resolves the chain and checksummed address of a label route
+func labelTarget(w http.ResponseWriter, r *http.Request, registry *chain.Registry) (chain.Validator, string, bool) {
+	v, err := validatorForRequest(registry, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, "", false
+	}
+
+	address, err := validator.ToChecksumAddress(chi.URLParam(r, "address"))
+	if err != nil {
+		http.Error(w, "Invalid address format", http.StatusBadRequest)
+		return nil, "", false
+	}
+	return v, address, true
+}
+
+// lookupLabel returns the caller's label for an address, if any. Lookup
+// failures are logged and treated as no label.
+func lookupLabel(r *http.Request, store labels.Store, chainName, address string) *labels.Label {
+	label, err := store.Get(r.Context(), auth.APIKeyFromContext(r.Context()), chainName, address)
+	if err != nil {
+		logrus.Errorf("Failed to look up label: %v", err)
+		return nil
+	}
+	return label
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/auth"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/poisoning"
+)
+
+// maxCounterparties bounds the supplied address set of one check
+const maxCounterparties = 1000
+
+type PoisoningCheckRequest struct {
+	Address        string   `json:"address"`
+	Chain          string   `json:"chain,omitempty"`
+	Counterparties []string `json:"counterparties,omitempty"`
+	// IncludeRecent compares against addresses recently seen for the API key;
+	// defaults to true
+	IncludeRecent *bool `json:"includeRecent,omitempty"`
+}
+
+type PoisoningCheckResponse struct {
+	*poisoning.Report
+	Error string `json:"error,omitempty"`
+}
+
+// PoisoningCheckHandler reports whether an address imitates a known
+// counterparty, a sign of address poisoning
+func PoisoningCheckHandler(detector *poisoning.Detector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req PoisoningCheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Address == "" {
+			http.Error(w, "Address is required", http.StatusBadRequest)
+			return
+		}
+		if len(req.Counterparties) > maxCounterparties {
+			http.Error(w, fmt.Sprintf("At most %d counterparties are allowed", maxCounterparties), http.StatusBadRequest)
+			return
+		}
+		includeRecent := req.IncludeRecent == nil || *req.IncludeRecent
+		if req.Chain == "" {
+			req.Chain = defaultChain
+		}
+
+		apiKey := auth.APIKeyFromContext(r.Context())
+		report, err := detector.Check(r.Context(), apiKey, req.Chain, req.Address, req.Counterparties, includeRecent)
+		response := PoisoningCheckResponse{Report: report}
+		if err != nil {
+			response.Error = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
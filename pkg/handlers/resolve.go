@@ -3,20 +3,27 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/ens"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/screening"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
 )
 
 type ResolveResponse struct {
-	Name    string `json:"name"`
-	Address string `json:"address"`
-	Error   string `json:"error,omitempty"`
+	Name      string             `json:"name"`
+	Address   string             `json:"address"`
+	Label     *labels.Label      `json:"label,omitempty"`
+	Screening *screening.Verdict `json:"screening,omitempty"`
+	Warnings  []ens.Warning      `json:"warnings"`
+	Error     string             `json:"error,omitempty"`
 }
 
 // ResolveENSHandler handles ENS name resolution requests
-func ResolveENSHandler(validator chain.Validator) http.HandlerFunc {
+func ResolveENSHandler(validator chain.Validator, screener *screening.Service, risk *ens.RiskChecker, store labels.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
@@ -25,8 +32,12 @@ func ResolveENSHandler(validator chain.Validator) http.HandlerFunc {
 			http.Error(w, "Name parameter is required", http.StatusBadRequest)
 			return
 		}
+		// Homoglyphs arrive percent-encoded in the path
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
 
-		address, err := validator.ResolveENS(name)
+		address, err := validator.ResolveENS(r.Context(), name)
 		response := ResolveResponse{
 			Name: name,
 		}
@@ -34,9 +45,14 @@ func ResolveENSHandler(validator chain.Validator) http.HandlerFunc {
 		if err != nil {
 			response.Error = err.Error()
 			response.Address = "0x0000000000000000000000000000000000000000"
+			response.Screening = screener.Screen(validator.GetChainName(), name)
 		} else {
 			response.Address = address
+			response.Label = lookupLabel(r, store, validator.GetChainName(), address)
+			// Screen the address the name points to, not just the name
+			response.Screening = screener.Screen(validator.GetChainName(), name, address)
 		}
+		response.Warnings = risk.Check(r.Context(), name, address, validator.ResolveENS)
 
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			logrus.Errorf("Failed to encode response: %v", err)
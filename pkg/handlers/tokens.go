@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/cache"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/tokens"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+)
+
+// Bounds on a single token query; every token and spender adds calls to the multicall
+const (
+	maxTokensPerRequest   = 50
+	maxSpendersPerRequest = 20
+)
+
+const unknownDecimalsWarning = "decimals() failed, so the amount is not formatted"
+
+type TokenBalancesRequest struct {
+	Owner  string   `json:"owner"`
+	Tokens []string `json:"tokens"`
+}
+
+type TokenAllowancesRequest struct {
+	Owner    string   `json:"owner"`
+	Tokens   []string `json:"tokens"`
+	Spenders []string `json:"spenders"`
+}
+
+type TokenBalanceResponse struct {
+	Token     string `json:"token"`
+	Symbol    string `json:"symbol,omitempty"`
+	Decimals  *uint8 `json:"decimals,omitempty"`
+	Balance   string `json:"balance,omitempty"`
+	Formatted string `json:"formatted,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Warning explains a missing formatted amount
+	Warning string `json:"warning,omitempty"`
+}
+
+type TokenAllowanceResponse struct {
+	Token     string `json:"token"`
+	Symbol    string `json:"symbol,omitempty"`
+	Decimals  *uint8 `json:"decimals,omitempty"`
+	Spender   string `json:"spender"`
+	Allowance string `json:"allowance,omitempty"`
+	Formatted string `json:"formatted,omitempty"`
+	Unlimited bool   `json:"unlimited"`
+	Error     string `json:"error,omitempty"`
+	// Warning explains a missing formatted amount
+	Warning string `json:"warning,omitempty"`
+}
+
+type TokenBalancesResponse struct {
+	Chain    string                 `json:"chain"`
+	Owner    string                 `json:"owner"`
+	Balances []TokenBalanceResponse `json:"balances"`
+}
+
+type TokenAllowancesResponse struct {
+	Chain      string                   `json:"chain"`
+	Owner      string                   `json:"owner"`
+	Allowances []TokenAllowanceResponse `json:"allowances"`
+	Unlimited  int                      `json:"unlimitedCount"`
+}
+
+// TokenBalancesHandler returns an owner's balances for a list of ERC-20 tokens
+func TokenBalancesHandler(registry *chain.Registry, addressCache *cache.AddressCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		validator, err := validatorForRequest(registry, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		var req TokenBalancesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if err := validateTokenQuery(validator, req.Owner, req.Tokens, nil); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		balances, err := tokens.NewCachedReader(validator, validator.GetChainName(), addressCache).Balances(r.Context(), req.Owner, req.Tokens)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		response := TokenBalancesResponse{
+			Chain:    validator.GetChainName(),
+			Owner:    req.Owner,
+			Balances: make([]TokenBalanceResponse, 0, len(balances)),
+		}
+		for _, b := range balances {
+			item := TokenBalanceResponse{
+				Token:  b.Token,
+				Symbol: b.Symbol,
+				Error:  b.Error,
+			}
+			if !b.DecimalsUnknown {
+				item.Decimals = &b.Decimals
+			}
+			if b.Amount != nil {
+				item.Balance = b.Amount.String()
+				if formatted, ok := b.Formatted(); ok {
+					item.Formatted = formatted
+				} else {
+					item.Warning = unknownDecimalsWarning
+				}
+			}
+			response.Balances = append(response.Balances, item)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// TokenAllowancesHandler returns allowance(owner, spender) for every token
+// and spender pair and flags unlimited approvals
+func TokenAllowancesHandler(registry *chain.Registry, addressCache *cache.AddressCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		validator, err := validatorForRequest(registry, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		var req TokenAllowancesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if len(req.Spenders) == 0 {
+			http.Error(w, "At least one spender is required", http.StatusBadRequest)
+			return
+		}
+		if err := validateTokenQuery(validator, req.Owner, req.Tokens, req.Spenders); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		allowances, err := tokens.NewCachedReader(validator, validator.GetChainName(), addressCache).Allowances(r.Context(), req.Owner, req.Tokens, req.Spenders)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		response := TokenAllowancesResponse{
+			Chain:      validator.GetChainName(),
+			Owner:      req.Owner,
+			Allowances: make([]TokenAllowanceResponse, 0, len(allowances)),
+		}
+		for _, a := range allowances {
+			item := TokenAllowanceResponse{
+				Token:     a.Token,
+				Symbol:    a.Symbol,
+				Spender:   a.Spender,
+				Unlimited: a.Unlimited,
+				Error:     a.Error,
+			}
+			if !a.DecimalsUnknown {
+				item.Decimals = &a.Decimals
+			}
+			if a.Amount != nil {
+				item.Allowance = a.Amount.String()
+				if formatted, ok := a.Formatted(); ok {
+					item.Formatted = formatted
+				} else {
+					item.Warning = unknownDecimalsWarning
+				}
+			}
+			if a.Unlimited {
+				response.Unlimited++
+			}
+			response.Allowances = append(response.Allowances, item)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+func validateTokenQuery(validator chain.Validator, owner string, tokenList, spenders []string) error {
+	if !validator.IsValidAddress(owner) {
+		return fmt.Errorf("invalid owner address: %s", owner)
+	}
+	if len(tokenList) == 0 {
+		return fmt.Errorf("at least one token is required")
+	}
+	if len(tokenList) > maxTokensPerRequest {
+		return fmt.Errorf("at most %d tokens are allowed", maxTokensPerRequest)
+	}
+	if len(spenders) > maxSpendersPerRequest {
+		return fmt.Errorf("at most %d spenders are allowed", maxSpendersPerRequest)
+	}
+	for _, token := range tokenList {
+		if !validator.IsValidAddress(token) {
+			return fmt.Errorf("invalid token address: %s", token)
+		}
+	}
+	for _, spender := range spenders {
+		if !validator.IsValidAddress(spender) {
+			return fmt.Errorf("invalid spender address: %s", spender)
+		}
+	}
+	return nil
+}
@@ -6,16 +6,20 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/labels"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/screening"
 	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
 )
 
 type ValidateResponse struct {
-	Address string `json:"address"`
-	IsValid bool   `json:"isValid"`
+	Address   string             `json:"address"`
+	IsValid   bool               `json:"isValid"`
+	Label     *labels.Label      `json:"label,omitempty"`
+	Screening *screening.Verdict `json:"screening,omitempty"`
 }
 
 // ValidateAddressHandler handles Ethereum address validation requests
-func ValidateAddressHandler(validator chain.Validator) http.HandlerFunc {
+func ValidateAddressHandler(validator chain.Validator, screener *screening.Service, store labels.Store) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 
@@ -33,8 +37,10 @@ func ValidateAddressHandler(validator chain.Validator) http.HandlerFunc {
 		}).Debug("EIP-55 validation result")
 
 		resp := ValidateResponse{
-			Address: address,
-			IsValid: isValid,
+			Address:   address,
+			IsValid:   isValid,
+			Label:     lookupLabel(r, store, validator.GetChainName(), address),
+			Screening: screener.Screen(validator.GetChainName(), address),
 		}
 
 		if err := json.NewEncoder(w).Encode(resp); err != nil {
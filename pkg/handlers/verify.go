@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/verify"
+)
+
+type VerifyMessageRequest struct {
+	Address   string `json:"address"`
+	Message   string `json:"message"`
+	Encoding  string `json:"encoding,omitempty"`
+	Signature string `json:"signature"`
+}
+
+type VerifyResponse struct {
+	Address     string `json:"address"`
+	ENSName     string `json:"ensName,omitempty"`
+	Recovered   string `json:"recovered,omitempty"`
+	Valid       bool   `json:"valid"`
+	Method      string `json:"method,omitempty"`
+	MessageHash string `json:"messageHash,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// VerifyMessageHandler verifies an EIP-191 personal_sign signature against an
+// address or ENS name, falling back to EIP-1271/EIP-6492 for smart wallets
+func VerifyMessageHandler(registry *chain.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		v, err := validatorForRequest(registry, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		var req VerifyMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Address == "" || req.Signature == "" {
+			http.Error(w, "Address and signature are required", http.StatusBadRequest)
+			return
+		}
+
+		message, err := decodeMessage(req.Message, req.Encoding)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		signature, err := validator.DecodeHex(req.Signature)
+		if err != nil {
+			http.Error(w, "Invalid signature encoding", http.StatusBadRequest)
+			return
+		}
+
+		hash := verify.HashPersonalMessage(message)
+		response := VerifyResponse{
+			Address:     req.Address,
+			MessageHash: hash.Hex(),
+		}
+
+		address, ensName, err := resolveAddressOrName(r.Context(), v, req.Address)
+		if err != nil {
+			response.Error = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+		} else {
+			response.Address = address.Hex()
+			response.ENSName = ensName
+
+			result, err := verify.NewVerifier(v).Verify(r.Context(), address, hash, signature)
+			if err != nil {
+				response.Error = err.Error()
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				response.Valid = result.Valid
+				response.Method = result.Method
+				response.Recovered = result.Recovered
+			}
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// resolveAddressOrName accepts either a hex address or an ENS name
+func resolveAddressOrName(ctx context.Context, v chain.Validator, input string) (common.Address, string, error) {
+	if v.IsValidAddress(input) {
+		return common.HexToAddress(input), "", nil
+	}
+	if !strings.Contains(input, ".") {
+		return common.Address{}, "", fmt.Errorf("invalid address or ENS name: %s", input)
+	}
+
+	resolved, err := v.ResolveENS(ctx, input)
+	if err != nil {
+		return common.Address{}, "", fmt.Errorf("failed to resolve %s: %w", input, err)
+	}
+	return common.HexToAddress(resolved), input, nil
+}
+
+func decodeMessage(message, encoding string) ([]byte, error) {
+	switch encoding {
+	case "", "utf8":
+		return []byte(message), nil
+	case "hex":
+		data, err := validator.DecodeHex(message)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hex message: %w", err)
+		}
+		return data, nil
+	default:
+		return nil, fmt.Errorf("unsupported message encoding %q: expected utf8 or hex", encoding)
+	}
+}
+
+type VerifyTypedDataRequest struct {
+	Address   string             `json:"address,omitempty"`
+	TypedData apitypes.TypedData `json:"typedData"`
+	Signature string             `json:"signature"`
+}
+
+type TypedDataHashesResponse struct {
+	EncodedType     string `json:"encodedType"`
+	DomainSeparator string `json:"domainSeparator"`
+	StructHash      string `json:"structHash"`
+	Digest          string `json:"digest"`
+}
+
+type TypedDataChecksResponse struct {
+	ChainID                   string `json:"chainId"`
+	ChainIDMatches            *bool  `json:"chainIdMatches,omitempty"`
+	VerifyingContractDeployed *bool  `json:"verifyingContractDeployed,omitempty"`
+}
+
+type VerifyTypedDataResponse struct {
+	Address   string                   `json:"address,omitempty"`
+	ENSName   string                   `json:"ensName,omitempty"`
+	Recovered string                   `json:"recovered,omitempty"`
+	Valid     bool                     `json:"valid"`
+	Method    string                   `json:"method,omitempty"`
+	Hashes    *TypedDataHashesResponse `json:"hashes,omitempty"`
+	Checks    *TypedDataChecksResponse `json:"checks,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// VerifyTypedDataHandler hashes an EIP-712 payload, recovers or verifies its
+// signer and checks the domain against the selected chain
+func VerifyTypedDataHandler(registry *chain.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		v, err := validatorForRequest(registry, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		var req VerifyTypedDataRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Signature == "" {
+			http.Error(w, "Signature is required", http.StatusBadRequest)
+			return
+		}
+		signature, err := validator.DecodeHex(req.Signature)
+		if err != nil {
+			http.Error(w, "Invalid signature encoding", http.StatusBadRequest)
+			return
+		}
+
+		response := VerifyTypedDataResponse{
+			Address: req.Address,
+		}
+
+		hashes, err := verify.HashTypedData(&req.TypedData)
+		if err != nil {
+			response.Error = err.Error()
+			w.WriteHeader(http.StatusBadRequest)
+			if err := json.NewEncoder(w).Encode(response); err != nil {
+				logrus.Errorf("Failed to encode response: %v", err)
+			}
+			return
+		}
+		response.Hashes = &TypedDataHashesResponse{
+			EncodedType:     hashes.EncodedType,
+			DomainSeparator: hashes.DomainSeparator.Hex(),
+			StructHash:      hashes.StructHash.Hex(),
+			Digest:          hashes.Digest.Hex(),
+		}
+		response.Checks = checkTypedDataDomain(r, v, req.TypedData.Domain)
+
+		if req.Address == "" {
+			// No claimed signer: report who signed the payload. Any signature
+			// recovers to some address, so nothing has been verified.
+			recovered, err := verify.RecoverSigner(hashes.Digest, signature)
+			if err != nil {
+				response.Error = err.Error()
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				response.Recovered = recovered.Hex()
+				response.Method = verify.MethodECRecover
+			}
+		} else {
+			address, ensName, err := resolveAddressOrName(r.Context(), v, req.Address)
+			if err != nil {
+				response.Error = err.Error()
+				w.WriteHeader(http.StatusBadRequest)
+			} else {
+				response.Address = address.Hex()
+				response.ENSName = ensName
+
+				result, err := verify.NewVerifier(v).Verify(r.Context(), address, hashes.Digest, signature)
+				if err != nil {
+					response.Error = err.Error()
+					w.WriteHeader(http.StatusBadRequest)
+				} else {
+					response.Valid = result.Valid
+					response.Method = result.Method
+					response.Recovered = result.Recovered
+				}
+			}
+		}
+		// A signature for another chain's domain can be replayed there, not
+		// here
+		if matches := response.Checks.ChainIDMatches; matches != nil && !*matches && response.Valid {
+			response.Valid = false
+			response.Error = fmt.Sprintf("domain chainId %s does not match chain ID %s", (*big.Int)(req.TypedData.Domain.ChainId), response.Checks.ChainID)
+		}
+
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// checkTypedDataDomain compares the domain's chainId with the selected chain
+// and checks that its verifyingContract is deployed there
+func checkTypedDataDomain(r *http.Request, v chain.Validator, domain apitypes.TypedDataDomain) *TypedDataChecksResponse {
+	chainID := v.GetChainID()
+	checks := &TypedDataChecksResponse{
+		ChainID: chainID.String(),
+	}
+
+	if domain.ChainId != nil {
+		matches := (*big.Int)(domain.ChainId).Cmp(chainID) == 0
+		checks.ChainIDMatches = &matches
+	}
+
+	if domain.VerifyingContract != "" {
+		deployed, err := v.IsContract(r.Context(), domain.VerifyingContract)
+		if err != nil {
+			logrus.Warnf("Failed to check verifying contract %s: %v", domain.VerifyingContract, err)
+		} else {
+			checks.VerifyingContractDeployed = &deployed
+		}
+	}
+	return checks
+}
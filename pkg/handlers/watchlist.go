@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/auth"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/validator/chain"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/watchlist"
+)
+
+type WatchlistAddRequest struct {
+	// Target is an address or an ENS name
+	Target string `json:"target"`
+	Chain  string `json:"chain,omitempty"`
+}
+
+type WatchlistResponse struct {
+	Items []*watchlist.Item `json:"items"`
+}
+
+type WatchlistEventsResponse struct {
+	Events []watchlist.Event `json:"events"`
+}
+
+// AddWatchlistItemHandler starts watching an address or ENS name. The item
+// is checked once right away to record its baseline state.
+func AddWatchlistItemHandler(registry *chain.Registry, list *watchlist.Watchlist, poller *watchlist.Poller) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req WatchlistAddRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Chain == "" {
+			req.Chain = defaultChain
+		}
+		if _, err := registry.Get(req.Chain); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		target := strings.TrimSpace(req.Target)
+		kind := watchlist.KindAddress
+		if validator.IsValidAddress(target) {
+			target, _ = validator.ToChecksumAddress(target)
+		} else if strings.Contains(target, ".") {
+			kind = watchlist.KindENS
+			target = strings.ToLower(target)
+		} else {
+			http.Error(w, "Target must be an address or an ENS name", http.StatusBadRequest)
+			return
+		}
+
+		item, created, err := list.Add(auth.APIKeyFromContext(r.Context()), req.Chain, kind, target)
+		if errors.Is(err, watchlist.ErrLimitReached) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logrus.Errorf("Failed to add watchlist item: %v", err)
+			http.Error(w, "Failed to add watchlist item", http.StatusInternalServerError)
+			return
+		}
+
+		if created {
+			if checked, _, err := poller.Check(r.Context(), item.ID); err == nil {
+				item = checked
+			}
+			w.WriteHeader(http.StatusCreated)
+		}
+		if err := json.NewEncoder(w).Encode(item); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// ListWatchlistHandler lists the caller's watched items and their last
+// known state
+func ListWatchlistHandler(list *watchlist.Watchlist) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		response := WatchlistResponse{Items: list.List(auth.APIKeyFromContext(r.Context()))}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// GetWatchlistItemHandler returns one of the caller's watched items
+func GetWatchlistItemHandler(list *watchlist.Watchlist) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		item, err := list.Get(auth.APIKeyFromContext(r.Context()), chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(item); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// DeleteWatchlistItemHandler stops watching one of the caller's items
+func DeleteWatchlistItemHandler(list *watchlist.Watchlist) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := list.Remove(auth.APIKeyFromContext(r.Context()), chi.URLParam(r, "id")); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// WatchlistEventsHandler lists changes detected on the caller's items,
+// newest first. Optional query parameters: item, since (RFC 3339) and limit.
+func WatchlistEventsHandler(list *watchlist.Watchlist) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query()
+		var since time.Time
+		if s := query.Get("since"); s != "" {
+			parsed, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				http.Error(w, "since must be an RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+			since = parsed
+		}
+		limit := 0
+		if s := query.Get("limit"); s != "" {
+			parsed, err := strconv.Atoi(s)
+			if err != nil || parsed < 0 {
+				http.Error(w, "limit must be a non-negative integer", http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+
+		events := list.Events(auth.APIKeyFromContext(r.Context()), query.Get("item"), since, limit)
+		if err := json.NewEncoder(w).Encode(WatchlistEventsResponse{Events: events}); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/auth"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/watchlist"
+	"github.com/sivaratrisrinivas/web3/blockCheck/internal/webhook"
+)
+
+type WebhookRegisterRequest struct {
+	URL string `json:"url"`
+	// Events filters the event types delivered, e.g. "ens-*" or
+	// "screening-changed"; empty delivers every event
+	Events []string `json:"events,omitempty"`
+}
+
+type WebhooksResponse struct {
+	Endpoints []*webhook.Endpoint `json:"endpoints"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []*webhook.Delivery `json:"deliveries"`
+}
+
+// RegisterWebhookHandler registers an endpoint receiving the caller's
+// events. The response carries the signing secret, which is not shown again.
+func RegisterWebhookHandler(webhooks *webhook.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var req WebhookRegisterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		for _, filter := range req.Events {
+			if !knownEventFilter(filter) {
+				http.Error(w, fmt.Sprintf("Unknown event type %q", filter), http.StatusBadRequest)
+				return
+			}
+		}
+
+		endpoint, err := webhooks.Register(auth.APIKeyFromContext(r.Context()), req.URL, req.Events)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(endpoint); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// ListWebhooksHandler lists the caller's endpoints
+func ListWebhooksHandler(webhooks *webhook.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		response := WebhooksResponse{Endpoints: webhooks.Endpoints(auth.APIKeyFromContext(r.Context()))}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// DeleteWebhookHandler removes one of the caller's endpoints
+func DeleteWebhookHandler(webhooks *webhook.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := webhooks.RemoveEndpoint(auth.APIKeyFromContext(r.Context()), chi.URLParam(r, "id")); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// WebhookDeliveriesHandler lists the caller's deliveries with their
+// attempts, newest first. Optional query parameters: endpoint and status
+// (pending, delivered or dead for the dead-letter queue).
+func WebhookDeliveriesHandler(webhooks *webhook.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		status := r.URL.Query().Get("status")
+		switch status {
+		case "", webhook.StatusPending, webhook.StatusDelivered, webhook.StatusDead:
+		default:
+			http.Error(w, "status must be pending, delivered or dead", http.StatusBadRequest)
+			return
+		}
+
+		deliveries := webhooks.Deliveries(auth.APIKeyFromContext(r.Context()), r.URL.Query().Get("endpoint"), status)
+		if err := json.NewEncoder(w).Encode(WebhookDeliveriesResponse{Deliveries: deliveries}); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// WebhookDeliveryHandler returns one of the caller's deliveries
+func WebhookDeliveryHandler(webhooks *webhook.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		delivery, err := webhooks.Delivery(auth.APIKeyFromContext(r.Context()), chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(delivery); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// ReplayWebhookDeliveryHandler sends one of the caller's deliveries again
+func ReplayWebhookDeliveryHandler(webhooks *webhook.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		delivery, err := webhooks.Replay(auth.APIKeyFromContext(r.Context()), chi.URLParam(r, "id"))
+		if errors.Is(err, webhook.ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		if err := json.NewEncoder(w).Encode(delivery); err != nil {
+			logrus.Errorf("Failed to encode response: %v", err)
+		}
+	}
+}
+
+// knownEventFilter reports whether an event filter matches any event type
+func knownEventFilter(filter string) bool {
+	for _, eventType := range watchlist.EventTypes {
+		if webhook.MatchesEvent(filter, eventType) {
+			return true
+		}
+	}
+	return false
+}